@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -20,7 +21,15 @@ func main() {
 	// Create and run application
 	application := app.NewApp(VERSION, REPOSITORY_URL)
 
+	// Run a non-interactive subcommand when arguments are given
+	if len(os.Args) > 1 {
+		os.Exit(application.RunCommand(os.Args[1:]))
+	}
+
 	if err := application.Run(); err != nil {
+		if errors.Is(err, app.ErrInterrupted) {
+			os.Exit(app.ExitInterrupted)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
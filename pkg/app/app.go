@@ -1,48 +1,118 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/cache"
 	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/history"
 	"github.com/xiaoxu123195/atm/pkg/i18n"
 	"github.com/xiaoxu123195/atm/pkg/manager"
 	versionpkg "github.com/xiaoxu123195/atm/pkg/version"
 )
 
-// VersionInfo stores version information for a tool
-type VersionInfo struct {
-	CurrentVersion string
-	LatestVersion  string
-}
+// ConcurrencyEnv is the environment variable that limits concurrent registry requests
+const ConcurrencyEnv = "ATM_CONCURRENCY"
+
+// CacheTTLEnv is the environment variable that sets how long cached versions stay fresh
+const CacheTTLEnv = "ATM_CACHE_TTL"
+
+// TimeoutEnv is the environment variable that bounds each install, update or uninstall
+const TimeoutEnv = "ATM_TIMEOUT"
+
+// RegistryTimeoutEnv is the environment variable that bounds each registry request
+const RegistryTimeoutEnv = "ATM_REGISTRY_TIMEOUT"
+
+// UpdateCheckIntervalEnv is the environment variable that sets how long the
+// result of a check for a new atm release is reused
+const UpdateCheckIntervalEnv = "ATM_UPDATE_CHECK_INTERVAL"
+
+// GitHubAPIURLEnv is the environment variable that sets the base URL of the
+// GitHub API, e.g. a GitHub Enterprise server or a mirror
+const GitHubAPIURLEnv = "ATM_GITHUB_API_URL"
+
+// GitHubTokenEnv is the environment variable holding a token for GitHub API requests
+const GitHubTokenEnv = "GITHUB_TOKEN"
+
+// defaultConcurrency is used when no concurrency limit is configured
+const defaultConcurrency = 5
+
+// defaultCacheTTL is used when no cache TTL is configured
+const defaultCacheTTL = time.Hour
+
+// backgroundGracePeriod is how long Close lets background refreshes finish
+// before it cancels them
+const backgroundGracePeriod = time.Second
+
+// defaultUpdateCheckInterval is used when no update check interval is configured
+const defaultUpdateCheckInterval = 24 * time.Hour
 
 // App represents the main application
 type App struct {
+	// ctx is canceled when the user interrupts atm (see handleInterrupt)
+	ctx context.Context
+
 	version        string
 	repositoryURL  string
 	config         *config.Config
 	packageManager *manager.PackageManager
 	versionChecker *versionpkg.Checker
+	// github reads release notes; it is configured by configureGitHub
+	github *github.Client
 
 	// Cache
 	installedTools   []config.Tool
 	uninstalledTools []config.Tool
-	versionCache     map[string]*VersionInfo
+	versionCache     *cache.VersionCache
+
+	// history records version changes for rollback and auditLog records every
+	// operation; both are nil if there is no state directory
+	history  *history.Store
+	auditLog *audit.Log
+
+	// refresh bypasses the on-disk version cache
+	refresh bool
+	// semaphore limits concurrent registry requests, including background refreshes
+	semaphore chan struct{}
+	// background tracks refreshes of stale cache entries; refreshing holds their packages
+	background sync.WaitGroup
+	refreshing sync.Map
+	// backgroundCtx is the context of background refreshes; Close cancels it
+	// with stopBackground
+	backgroundCtx  context.Context
+	stopBackground context.CancelFunc
+	backgroundOnce sync.Once
+
+	// spinner is the spinner shown while a package operation runs; prompts
+	// during the operation pause it
+	spinner *spinner.Spinner
+	// prefixOffered is set once the user prefix was offered, so a batch of
+	// failing operations asks only once
+	prefixOffered bool
 }
 
 // NewApp creates a new application instance
 func NewApp(version, repositoryURL string) *App {
 	return &App{
+		ctx:              context.Background(),
 		version:          version,
 		repositoryURL:    repositoryURL,
-		packageManager:   manager.NewPackageManager(),
 		versionChecker:   versionpkg.NewChecker(version, repositoryURL),
-		versionCache:     make(map[string]*VersionInfo),
+		versionCache:     cache.NewVersionCache(),
 		installedTools:   []config.Tool{},
 		uninstalledTools: []config.Tool{},
 	}
@@ -50,20 +120,22 @@ func NewApp(version, repositoryURL string) *App {
 
 // Run starts the application
 func (a *App) Run() error {
+	defer a.handleInterrupt()()
+
 	// Display welcome message
 	fmt.Println(color.CyanString("\n" + i18n.T("app.title") + "\n"))
 
-	// Load configuration
-	if err := a.loadConfig(); err != nil {
-		return fmt.Errorf("%s: %w", i18n.T("config.loadError"), err)
+	if err := a.prepare(); err != nil {
+		return err
 	}
-
-	// Initialize tools cache
-	a.initializeToolsCache()
+	defer a.Close()
 
 	// Check for updates (can be skipped with environment variable)
 	if os.Getenv("ATM_SKIP_VERSION_CHECK") != "true" {
 		a.checkForUpdates()
+		if a.interrupted() {
+			return ErrInterrupted
+		}
 	}
 
 	// Main menu loop
@@ -80,6 +152,10 @@ func (a *App) Run() error {
 			a.handleQuery()
 		case "update":
 			a.handleUpdate()
+		case "version":
+			a.handleChooseVersion()
+		case "rollback":
+			a.handleRollback()
 		case "uninstall":
 			a.handleUninstall()
 		case "exit":
@@ -87,10 +163,93 @@ func (a *App) Run() error {
 			return nil
 		}
 
+		if a.interrupted() {
+			return ErrInterrupted
+		}
+
 		fmt.Println("\n" + i18n.T("app.separator") + "\n")
 	}
 }
 
+// prepare loads the configuration and initializes the tools cache
+func (a *App) prepare() error {
+	// Load configuration
+	if err := a.loadConfig(); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("config.loadError"), err)
+	}
+
+	// Select the package manager backend
+	backend, err := manager.DetectBackend(a.ctx, a.config.PackageManager, npmPackages(a.config.Tools))
+	if err != nil {
+		return err
+	}
+	a.packageManager = manager.NewPackageManager(backend)
+	a.packageManager.SetTimeouts(a.timeouts())
+	a.configureGitHub(a.config)
+	if binDir, err := config.BinDir(); err == nil {
+		stateDir, _ := config.StateDir()
+		a.packageManager.SetReleaseDirs(binDir, stateDir)
+	}
+	a.packageManager.SetReleaseBins(releaseBins(a.config.Tools))
+	a.semaphore = make(chan struct{}, a.concurrencyLimit())
+
+	// Load cached registry versions unless a refresh was requested
+	if !a.refresh && a.cacheTTL() > 0 {
+		if path, err := versionCachePath(); err == nil {
+			// A corrupt cache file is ignored and overwritten on exit
+			_ = a.versionCache.Load(path)
+		}
+	}
+
+	if dir, err := config.StateDir(); err == nil {
+		a.history = history.NewStore(filepath.Join(dir, history.FileName))
+		a.auditLog = audit.NewLog(filepath.Join(dir, audit.FileName))
+	}
+
+	// Initialize tools cache
+	a.initializeToolsCache()
+	if a.interrupted() {
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// Close waits up to backgroundGracePeriod for background version refreshes,
+// cancels those still running and saves the version cache
+func (a *App) Close() {
+	if a.config == nil {
+		return
+	}
+
+	a.backgroundContext()
+	done := make(chan struct{})
+	go func() {
+		a.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(backgroundGracePeriod):
+		// A canceled refresh keeps the cached version
+		a.stopBackground()
+		<-done
+	}
+	a.stopBackground()
+
+	if path, err := versionCachePath(); err == nil {
+		_ = a.versionCache.Save(path)
+	}
+}
+
+// versionCachePath returns the location of the on-disk version cache
+func versionCachePath() (string, error) {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "versions.json"), nil
+}
+
 // loadConfig loads the configuration file
 func (a *App) loadConfig() error {
 	cfg, err := config.Load()
@@ -107,16 +266,61 @@ func (a *App) initializeToolsCache() {
 	s.Suffix = " " + i18n.T("app.initializing")
 	s.Start()
 
+	_ = a.loadInstalledTools(a.ctx)
+
+	s.Stop()
+}
+
+// loadInstalledTools sorts the catalog tools into the installed and
+// uninstalled lists
+func (a *App) loadInstalledTools(ctx context.Context) error {
+	packages, err := a.installedVersions(ctx)
+
+	a.installedTools, a.uninstalledTools = []config.Tool{}, []config.Tool{}
 	for _, tool := range a.config.Tools {
-		installed, _ := a.packageManager.IsPackageInstalled(tool.Package)
-		if installed {
+		if current, installed := packages[tool.Package]; installed {
 			a.installedTools = append(a.installedTools, tool)
+			a.versionCache.SetCurrent(tool.Package, current)
 		} else {
 			a.uninstalledTools = append(a.uninstalledTools, tool)
 		}
 	}
+	return err
+}
+
+// installedVersions lists the packages in the global store and the tools
+// installed from other sources with their versions, reading the global store
+// and each other source once
+func (a *App) installedVersions(ctx context.Context) (map[string]string, error) {
+	// Read the global store once instead of querying each package
+	packages, err := a.packageManager.GetInstalledPackages(ctx)
+	if packages == nil {
+		packages = make(map[string]string)
+	}
 
-	s.Stop()
+	bySource := make(map[string][]string)
+	for _, tool := range a.config.Tools {
+		if !tool.IsNpm() {
+			// A package of the same name in the global store is not this tool
+			delete(packages, tool.Package)
+			bySource[tool.Source] = append(bySource[tool.Source], tool.Package)
+		}
+	}
+
+	for _, source := range config.SourceNames {
+		if len(bySource[source]) == 0 {
+			continue
+		}
+		versions, sourceErr := a.packageManager.GetVersionsFrom(ctx, source, bySource[source])
+		for pkg, version := range versions {
+			packages[pkg] = version
+		}
+		// Without pipx, cargo or go none of its tools can be installed
+		if sourceErr != nil && !errors.Is(sourceErr, manager.ErrNpmMissing) && err == nil {
+			err = sourceErr
+		}
+	}
+	return packages, err
 }
 
 // checkForUpdates checks for ATM updates
@@ -125,11 +329,11 @@ func (a *App) checkForUpdates() {
 	s.Suffix = " " + i18n.T("version.checking")
 	s.Start()
 
-	updateInfo := a.versionChecker.CheckForUpdates()
+	updateInfo := a.versionChecker.CheckForUpdates(a.ctx)
 	s.Stop()
 
 	if updateInfo.Error != nil {
-		// Silently ignore errors
+		reportUpdateCheckError(updateInfo.Error)
 		return
 	}
 
@@ -142,6 +346,7 @@ func (a *App) checkForUpdates() {
 		prompt := promptui.Select{
 			Label: i18n.T("version.updatePrompt") + " " + i18n.T("prompts.useArrowKeys"),
 			Items: []string{
+				i18n.T("version.selfUpdate"),
 				i18n.T("version.openRepository"),
 				i18n.T("version.skipUpdate"),
 			},
@@ -152,7 +357,13 @@ func (a *App) checkForUpdates() {
 			return
 		}
 
-		if index == 0 {
+		switch index {
+		case 0:
+			if a.selfUpdate(updateInfo.LatestVersion, false) == ExitOK {
+				fmt.Println(color.YellowString(i18n.T("version.restart")))
+			}
+			fmt.Println()
+		case 1:
 			if err := a.versionChecker.OpenRepository(); err == nil {
 				fmt.Println(color.GreenString(i18n.T("version.repositoryOpened")))
 			} else {
@@ -163,6 +374,26 @@ func (a *App) checkForUpdates() {
 	}
 }
 
+// reportUpdateCheckError tells the user why the update check failed when
+// they can do something about it: an exceeded rate limit or a rejected token.
+// Other failures, such as being offline, are retried on the next launch.
+func reportUpdateCheckError(err error) {
+	switch {
+	case errors.Is(err, github.ErrRateLimited):
+		message := i18n.T("version.rateLimited")
+		if reset, ok := github.RateLimitReset(err); ok {
+			message = i18n.T("version.rateLimitedUntil", reset.Local().Format("15:04"))
+		}
+		fmt.Println(color.YellowString("⚠ " + message))
+		if os.Getenv(GitHubTokenEnv) == "" {
+			fmt.Println(color.New(color.FgHiBlack).Sprint("  " + i18n.T("errors.hint", i18n.T("version.hint.rateLimited"))))
+		}
+	case github.IsUnauthorized(err):
+		fmt.Println(color.YellowString("⚠ " + i18n.T("version.tokenRejected")))
+		fmt.Println(color.New(color.FgHiBlack).Sprint("  " + i18n.T("errors.hint", i18n.T("version.hint.tokenRejected"))))
+	}
+}
+
 // showMainMenu displays the main menu and returns the selected action
 func (a *App) showMainMenu() (string, error) {
 	templates := &promptui.SelectTemplates{
@@ -178,6 +409,8 @@ func (a *App) showMainMenu() (string, error) {
 			i18n.T("menu.install"),
 			i18n.T("menu.query"),
 			i18n.T("menu.update"),
+			i18n.T("menu.version"),
+			i18n.T("menu.rollback"),
 			i18n.T("menu.uninstall"),
 			i18n.T("menu.exit"),
 		},
@@ -189,35 +422,228 @@ func (a *App) showMainMenu() (string, error) {
 		return "", err
 	}
 
-	actions := []string{"install", "query", "update", "uninstall", "exit"}
+	actions := []string{"install", "query", "update", "version", "rollback", "uninstall", "exit"}
 	return actions[index], nil
 }
 
-// fetchVersionsConcurrently fetches version information for multiple tools concurrently
-func (a *App) fetchVersionsConcurrently(tools []config.Tool) {
+// startSpinner shows a spinner while a package operation runs. Prompts shown
+// during the operation pause it.
+func (a *App) startSpinner(message string) *spinner.Spinner {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + message
+	s.Start()
+	a.spinner = s
+	return s
+}
+
+// concurrencyLimit returns the maximum number of concurrent registry requests:
+// ATM_CONCURRENCY, then the configured value, then the default
+func (a *App) concurrencyLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv(ConcurrencyEnv)); err == nil && limit > 0 {
+		return limit
+	}
+	if a.config != nil && a.config.Concurrency > 0 {
+		return a.config.Concurrency
+	}
+	return defaultConcurrency
+}
+
+// cacheTTL returns how long cached latest versions stay fresh:
+// ATM_CACHE_TTL, then the configured value, then the default
+func (a *App) cacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv(CacheTTLEnv)); err == nil {
+		return ttl
+	}
+	if a.config != nil && a.config.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(a.config.CacheTTL); err == nil {
+			return ttl
+		}
+	}
+	return defaultCacheTTL
+}
+
+// timeouts returns how long a package operation and a registry request may
+// take: ATM_TIMEOUT and ATM_REGISTRY_TIMEOUT, then the configured values, then the defaults
+func (a *App) timeouts() (time.Duration, time.Duration) {
+	operation, registry := manager.DefaultTimeout, manager.DefaultRegistryTimeout
+	if a.config != nil {
+		operation = parseDurationOr(a.config.Timeout, operation)
+		registry = parseDurationOr(a.config.RegistryTimeout, registry)
+	}
+	return parseDurationOr(os.Getenv(TimeoutEnv), operation), parseDurationOr(os.Getenv(RegistryTimeoutEnv), registry)
+}
+
+// configureGitHub points the update check, self-update, release notes and
+// installs from GitHub releases at the configured API, authenticated with
+// GITHUB_TOKEN. Requests go through the proxy in HTTPS_PROXY or HTTP_PROXY.
+func (a *App) configureGitHub(cfg *config.Config) {
+	_, registryTimeout := a.timeouts()
+	client := github.NewClient(&http.Client{Timeout: registryTimeout})
+	client.APIURL = githubAPIURL(cfg)
+	client.Token = os.Getenv(GitHubTokenEnv)
+
+	a.github = client
+	a.versionChecker.SetClient(client)
+	if dir, err := config.CacheDir(); err == nil {
+		a.versionChecker.SetCache(filepath.Join(dir, "update-check.json"), a.updateCheckInterval(cfg))
+	}
+	if a.packageManager != nil {
+		a.packageManager.SetGitHubClient(client)
+	}
+}
+
+// githubAPIURL returns the base URL of the GitHub API: ATM_GITHUB_API_URL,
+// then the configured value, then api.github.com
+func githubAPIURL(cfg *config.Config) string {
+	if apiURL := os.Getenv(GitHubAPIURLEnv); apiURL != "" && config.ValidateAPIURL(apiURL) == nil {
+		return apiURL
+	}
+	if cfg != nil && cfg.GitHubAPIURL != "" {
+		return cfg.GitHubAPIURL
+	}
+	return github.DefaultAPIURL
+}
+
+// releaseBins collects the executables the catalog names for tools from
+// GitHub releases
+func releaseBins(tools []config.Tool) map[string][]string {
+	bins := make(map[string][]string)
+	for _, tool := range tools {
+		if tool.SourceName() == config.SourceGitHubRelease && len(tool.Bin) > 0 {
+			bins[tool.Package] = tool.Bin
+		}
+	}
+	return bins
+}
+
+// npmPackages returns the packages of the tools installed from the npm registry
+func npmPackages(tools []config.Tool) []string {
+	var packages []string
+	for _, tool := range tools {
+		if tool.IsNpm() {
+			packages = append(packages, tool.Package)
+		}
+	}
+	return packages
+}
+
+// updateCheckInterval returns how long the result of an update check is
+// reused: ATM_UPDATE_CHECK_INTERVAL, then the configured value, then the
+// default. --refresh checks again.
+func (a *App) updateCheckInterval(cfg *config.Config) time.Duration {
+	if a.refresh {
+		return 0
+	}
+	interval := defaultUpdateCheckInterval
+	if cfg != nil {
+		interval = parseDurationOr(cfg.UpdateCheckInterval, interval)
+	}
+	return parseDurationOr(os.Getenv(UpdateCheckIntervalEnv), interval)
+}
+
+// parseDurationOr parses a duration, returning fallback if s is empty or invalid
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d
+	}
+	return fallback
+}
+
+// fetchVersionsConcurrently fetches the latest versions of multiple tools concurrently.
+// Cached versions are used as they are; stale ones are refreshed in the background.
+func (a *App) fetchVersionsConcurrently(ctx context.Context, tools []config.Tool) {
 	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 5) // Limit to 5 concurrent requests
+	ttl := a.cacheTTL()
 
 	for _, tool := range tools {
-		if _, exists := a.versionCache[tool.Package]; exists {
+		if versionInfo, exists := a.versionCache.Get(tool.Package); exists && versionInfo.LatestVersion != "" &&
+			versionInfo.Constraint == tool.Version {
+			if !versionInfo.Fresh(ttl) {
+				a.refreshInBackground(tool)
+			}
 			continue // Skip if already cached
 		}
 
 		wg.Add(1)
 		go func(t config.Tool) {
 			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
-			defer func() { <-semaphore }() // Release
+			a.semaphore <- struct{}{}        // Acquire
+			defer func() { <-a.semaphore }() // Release
 
-			current, _ := a.packageManager.GetPackageVersion(t.Package)
-			latest, _ := a.packageManager.GetLatestVersion(t.Package)
-
-			a.versionCache[t.Package] = &VersionInfo{
-				CurrentVersion: current,
-				LatestVersion:  latest,
-			}
+			a.fetchLatestVersion(ctx, t)
 		}(tool)
 	}
 
 	wg.Wait()
 }
+
+// refreshInBackground re-fetches the latest version of a tool without blocking the
+// caller. It runs under the background context rather than the caller's, which
+// may end first.
+func (a *App) refreshInBackground(tool config.Tool) {
+	if _, running := a.refreshing.LoadOrStore(tool.Package, true); running {
+		return
+	}
+
+	ctx := a.backgroundContext()
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		defer a.refreshing.Delete(tool.Package)
+		a.semaphore <- struct{}{}
+		defer func() { <-a.semaphore }()
+
+		a.fetchLatestVersion(ctx, tool)
+	}()
+}
+
+// backgroundContext returns the context of background refreshes, which ends
+// with the context of the app or when Close stops the refreshes
+func (a *App) backgroundContext() context.Context {
+	a.backgroundOnce.Do(func() {
+		a.backgroundCtx, a.stopBackground = context.WithCancel(a.ctx)
+	})
+	return a.backgroundCtx
+}
+
+// fetchLatestVersion reads the newest version of a tool allowed by its catalog
+// constraint from the registry, revalidating the cached ETag. On failure the
+// cached version is kept.
+func (a *App) fetchLatestVersion(ctx context.Context, tool config.Tool) {
+	cached, _ := a.versionCache.Get(tool.Package)
+	if cached.Constraint != tool.Version {
+		// Resolved for a different constraint; the cached version cannot be reused
+		cached = cache.VersionInfo{}
+	}
+
+	if !tool.IsNpm() {
+		a.fetchLatestVersionFrom(ctx, tool)
+		return
+	}
+
+	result, err := a.packageManager.CheckLatestVersion(ctx, tool.Package, tool.Version, cached.ETag)
+	if err != nil {
+		return
+	}
+
+	latest := result.Version
+	if result.NotModified {
+		latest = cached.LatestVersion
+	}
+	a.versionCache.SetLatest(tool.Package, latest, tool.Version, result.ETag, time.Now())
+}
+
+// fetchLatestVersionFrom reads the latest version of a tool from the index
+// of its source. A tool with a version in the catalog stays at that version.
+func (a *App) fetchLatestVersionFrom(ctx context.Context, tool config.Tool) {
+	if tool.Version != "" {
+		a.versionCache.SetLatest(tool.Package, strings.TrimPrefix(tool.Version, "v"), tool.Version, "", time.Now())
+		return
+	}
+
+	latest, err := a.packageManager.GetLatestVersionFrom(ctx, tool.Source, tool.Package)
+	if err != nil {
+		return
+	}
+	a.versionCache.SetLatest(tool.Package, latest, "", "", time.Now())
+}
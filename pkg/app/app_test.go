@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+)
+
+// newTestApp creates an app whose registry is server, allowing concurrency
+// registry requests at a time
+func newTestApp(t *testing.T, server *httptest.Server, concurrency int) *App {
+	t.Helper()
+	backend, err := manager.NewBackend("npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewApp("1.0.0", "https://github.com/xiaoxu123195/atm")
+	a.packageManager = manager.NewPackageManager(backend)
+	registry := a.packageManager.Registry()
+	registry.Registry = server.URL + "/"
+	registry.ScopeRegistries = map[string]string{}
+	registry.Credentials = map[string]manager.Credential{}
+	registry.HTTPClient = server.Client()
+	a.semaphore = make(chan struct{}, concurrency)
+	return a
+}
+
+// TestFetchVersionsConcurrently fetches many tools at once, checking the
+// concurrency limit and that every result lands in the cache; run it with
+// go test -race
+func TestFetchVersionsConcurrently(t *testing.T) {
+	const limit = 3
+	var inFlight, maxInFlight, requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		fmt.Fprintf(w, `{"name":%q,"dist-tags":{"latest":"2.0.0"},"versions":{"1.0.0":{},"2.0.0":{}}}`, name)
+	}))
+	defer server.Close()
+	a := newTestApp(t, server, limit)
+
+	var tools []config.Tool
+	for i := 0; i < 12; i++ {
+		tools = append(tools, config.Tool{Name: fmt.Sprintf("Tool %d", i), Package: fmt.Sprintf("tool-%d", i)})
+	}
+	// A pinned tool resolves its constraint instead of the latest tag
+	tools = append(tools, config.Tool{Name: "Pinned", Package: "pinned", Version: "^1.0.0"})
+
+	done := make(chan struct{})
+	go func() {
+		// Readers run alongside the fetches
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				for _, tool := range tools {
+					a.versionCache.Get(tool.Package)
+				}
+			}
+		}
+	}()
+	a.fetchVersionsConcurrently(context.Background(), tools)
+	close(done)
+
+	for _, tool := range tools {
+		want := "2.0.0"
+		if tool.Version != "" {
+			want = "1.0.0"
+		}
+		if info, _ := a.versionCache.Get(tool.Package); info.LatestVersion != want {
+			t.Errorf("%s: latest = %q, want %q", tool.Package, info.LatestVersion, want)
+		}
+	}
+	if got := maxInFlight.Load(); got > limit {
+		t.Errorf("%d requests ran at once, want at most %d", got, limit)
+	}
+
+	// Fresh entries are used as they are
+	before := requests.Load()
+	a.fetchVersionsConcurrently(context.Background(), tools)
+	if got := requests.Load(); got != before {
+		t.Errorf("cached versions were fetched again (%d requests)", got-before)
+	}
+}
+
+// TestStaleVersionsRefreshInBackground checks that stale entries are returned
+// at once and refreshed by background goroutines; run it with go test -race
+func TestStaleVersionsRefreshInBackground(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"pkg","dist-tags":{"latest":"3.0.0"},"versions":{"3.0.0":{}}}`)
+	}))
+	defer server.Close()
+	a := newTestApp(t, server, 2)
+
+	tools := []config.Tool{{Name: "Pkg", Package: "pkg"}}
+	a.versionCache.SetLatest("pkg", "2.0.0", "", "", time.Now().Add(-30*24*time.Hour))
+
+	for i := 0; i < 5; i++ {
+		a.fetchVersionsConcurrently(context.Background(), tools)
+	}
+	a.background.Wait()
+
+	if info, _ := a.versionCache.Get("pkg"); info.LatestVersion != "3.0.0" {
+		t.Errorf("latest = %q after the background refresh, want 3.0.0", info.LatestVersion)
+	}
+}
+
+// TestCloseCancelsBackgroundRefreshes checks that Close does not wait for a
+// refresh that hangs and keeps the cached version
+func TestCloseCancelsBackgroundRefreshes(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	a := newTestApp(t, server, 1)
+	a.config = &config.Config{}
+
+	a.versionCache.SetLatest("pkg", "2.0.0", "", "", time.Now().Add(-30*24*time.Hour))
+	a.fetchVersionsConcurrently(context.Background(), []config.Tool{{Name: "Pkg", Package: "pkg"}})
+
+	start := time.Now()
+	a.Close()
+	if elapsed := time.Since(start); elapsed > backgroundGracePeriod+5*time.Second {
+		t.Errorf("Close took %s", elapsed)
+	}
+	if info, _ := a.versionCache.Get("pkg"); info.LatestVersion != "2.0.0" {
+		t.Errorf("latest = %q after a canceled refresh, want 2.0.0", info.LatestVersion)
+	}
+}
@@ -0,0 +1,502 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+)
+
+// Exit codes returned by RunCommand
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+	// Failures the package manager reported (see manager.ErrNotFound and others)
+	ExitNotFound   = 3
+	ExitPermission = 4
+	ExitNetwork    = 5
+	ExitEngine     = 6
+	ExitNpmMissing = 7
+	// ExitInterrupted is returned when the user pressed Ctrl+C (128 + SIGINT)
+	ExitInterrupted = 130
+)
+
+// command describes a non-interactive subcommand
+type command struct {
+	name  string
+	usage string
+	run   func(a *App, args []string) int
+}
+
+// commands lists the available subcommands in the order shown by help
+var commands = []command{
+	{"install", "cli.usage.install", (*App).cmdInstall},
+	{"update", "cli.usage.update", (*App).cmdUpdate},
+	{"uninstall", "cli.usage.uninstall", (*App).cmdUninstall},
+	{"versions", "cli.usage.versions", (*App).cmdVersions},
+	{"rollback", "cli.usage.rollback", (*App).cmdRollback},
+	{"history", "cli.usage.history", (*App).cmdHistory},
+	{"list", "cli.usage.list", (*App).cmdList},
+	{"outdated", "cli.usage.outdated", (*App).cmdOutdated},
+	{"lock", "cli.usage.lock", (*App).cmdLock},
+	{"sync", "cli.usage.sync", (*App).cmdSync},
+	{"doctor", "cli.usage.doctor", (*App).cmdDoctor},
+	{"dashboard", "cli.usage.dashboard", (*App).cmdDashboard},
+	{"self-update", "cli.usage.selfUpdate", (*App).cmdSelfUpdate},
+}
+
+// RunCommand runs a non-interactive subcommand and returns the process exit code.
+// A leading --refresh applies to every command; without a command the
+// interactive menu is started.
+func (a *App) RunCommand(args []string) int {
+	if args[0] == "--refresh" || args[0] == "-refresh" {
+		a.refresh = true
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		if err := a.Run(); err != nil {
+			if errors.Is(err, ErrInterrupted) {
+				return ExitInterrupted
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		a.printUsage(os.Stdout)
+		return ExitOK
+	case "version", "-v", "-version", "--version":
+		fmt.Println(a.version)
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			stop := a.handleInterrupt()
+			defer stop()
+
+			code := cmd.run(a, args[1:])
+			if a.interrupted() && code != ExitInterrupted {
+				// Interrupted outside a package operation, e.g. while fetching versions
+				code = reportNothingChanged()
+			}
+			a.Close()
+			return code
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.unknownCommand", args[0])))
+	a.printUsage(os.Stderr)
+	return ExitUsage
+}
+
+// printUsage prints the list of subcommands
+func (a *App) printUsage(w io.Writer) {
+	fmt.Fprintln(w, i18n.T("cli.usage"))
+	fmt.Fprintln(w)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\n", i18n.T(cmd.usage))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.T("cli.usage.interactive"))
+}
+
+// cmdInstall installs the named tools. A tool written as tool@version is
+// installed, upgraded or downgraded to that version, range or dist-tag.
+func (a *App) cmdInstall(args []string) int {
+	fs := newFlagSet("install")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(names) == 0 {
+		return usageError("cli.usage.install")
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	toolNames := make([]string, len(names))
+	requested := make(map[string]string)
+	for i, name := range names {
+		var version string
+		toolNames[i], version = splitToolSpec(name)
+		if tool, found := a.config.FindTool(toolNames[i]); found && version != "" {
+			requested[tool.Package] = version
+		}
+	}
+
+	tools, ok := a.resolveTools(toolNames)
+	if !ok {
+		return ExitUsage
+	}
+
+	code := ExitOK
+	for _, tool := range tools {
+		if a.interrupted() {
+			return ExitInterrupted
+		}
+		if spec, found := requested[tool.Package]; found {
+			if err := a.installRequestedVersion(tool, spec); err != nil {
+				a.reportFailure(os.Stderr, "install.failed", tool, err)
+				code = failureCode(code, err)
+			}
+			continue
+		}
+
+		if a.isInstalled(tool) {
+			fmt.Println(color.YellowString(i18n.T("cli.alreadyInstalled", tool.Name)))
+			continue
+		}
+
+		s := a.startSpinner(i18n.T("install.installing", tool.Name))
+
+		err := a.installTool(a.ctx, tool)
+		s.Stop()
+
+		if err != nil {
+			a.reportFailure(os.Stderr, "install.failed", tool, err)
+			code = failureCode(code, err)
+		} else {
+			fmt.Println(color.GreenString("✓ " + i18n.T("install.success", tool.Name)))
+		}
+	}
+
+	return code
+}
+
+// cmdUpdate updates the named tools, or every outdated tool with --all
+func (a *App) cmdUpdate(args []string) int {
+	fs := newFlagSet("update")
+	a.refreshFlag(fs)
+	all := fs.Bool("all", false, "update every installed tool")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(names) == 0 && !*all {
+		return usageError("cli.usage.update")
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	tools := a.installedTools
+	if !*all {
+		var ok bool
+		if tools, ok = a.resolveTools(names); !ok {
+			return ExitUsage
+		}
+	}
+
+	code := ExitOK
+	var candidates []config.Tool
+	for _, tool := range tools {
+		if !a.isInstalled(tool) {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.notInstalled", tool.Name)))
+			code = ExitFailure
+			continue
+		}
+		if tool.UpdatePolicy() == config.PolicyPinned {
+			if !*all {
+				fmt.Println(color.YellowString(i18n.T("cli.pinned", tool.Name)))
+			}
+			continue
+		}
+		candidates = append(candidates, tool)
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("update.checking")
+	s.Start()
+	a.fetchVersionsConcurrently(a.ctx, candidates)
+	s.Stop()
+
+	updated, skipped := 0, 0
+	for _, tool := range candidates {
+		if a.interrupted() {
+			return ExitInterrupted
+		}
+		versionInfo, _ := a.versionCache.Get(tool.Package)
+		if !hasUpdate(versionInfo) {
+			if versionInfo.LatestVersion == "" {
+				fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.latestUnknown", tool.Name)))
+				code = ExitFailure
+			} else if !*all {
+				fmt.Println(color.GreenString(i18n.T("cli.upToDate", tool.Name, versionInfo.CurrentVersion)))
+			}
+			continue
+		}
+		if *all && tool.UpdatePolicy() == config.PolicyNotify {
+			fmt.Println(color.YellowString(i18n.T("cli.notifySkipped", tool.Name, versionInfo.LatestVersion)))
+			skipped++
+			continue
+		}
+
+		s := a.startSpinner(i18n.T("update.updating", tool.Name))
+
+		err := a.updateTool(a.ctx, tool)
+		s.Stop()
+
+		if err != nil {
+			a.reportFailure(os.Stderr, "update.failed", tool, err)
+			code = failureCode(code, err)
+		} else {
+			fmt.Println(color.GreenString("✓ " + i18n.T("update.success", tool.Name)))
+			updated++
+		}
+	}
+
+	if *all && updated == 0 && skipped == 0 && code == ExitOK {
+		fmt.Println(color.GreenString(i18n.T("update.allUpToDate")))
+	}
+
+	return code
+}
+
+// cmdUninstall uninstalls the named tools without confirmation
+func (a *App) cmdUninstall(args []string) int {
+	fs := newFlagSet("uninstall")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(names) == 0 {
+		return usageError("cli.usage.uninstall")
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	tools, ok := a.resolveTools(names)
+	if !ok {
+		return ExitUsage
+	}
+
+	code := ExitOK
+	for _, tool := range tools {
+		if a.interrupted() {
+			return ExitInterrupted
+		}
+		if !a.isInstalled(tool) {
+			fmt.Println(color.YellowString(i18n.T("cli.notInstalled", tool.Name)))
+			continue
+		}
+
+		s := a.startSpinner(i18n.T("uninstall.uninstalling", tool.Name))
+
+		err := a.uninstallTool(a.ctx, tool)
+		s.Stop()
+
+		if err != nil {
+			a.reportFailure(os.Stderr, "uninstall.failed", tool, err)
+			code = failureCode(code, err)
+		} else {
+			fmt.Println(color.GreenString("✓ " + i18n.T("uninstall.success", tool.Name)))
+		}
+	}
+
+	return code
+}
+
+// cmdList prints every catalog tool with its installation status
+func (a *App) cmdList(args []string) int {
+	fs := newFlagSet("list")
+	a.refreshFlag(fs)
+	output := outputFlag(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+	if !validOutputFormat(*output) {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.invalidOutput", *output)))
+		return ExitUsage
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	a.fetchStatusVersions(a.config.Tools, *output)
+	return printStatuses(a.toolStatuses(a.config.Tools), *output)
+}
+
+// cmdOutdated prints the installed tools that have a newer version available
+func (a *App) cmdOutdated(args []string) int {
+	fs := newFlagSet("outdated")
+	a.refreshFlag(fs)
+	output := outputFlag(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+	if !validOutputFormat(*output) {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.invalidOutput", *output)))
+		return ExitUsage
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	a.fetchStatusVersions(a.installedTools, *output)
+
+	outdated := []ToolStatus{}
+	for _, status := range a.toolStatuses(a.installedTools) {
+		if status.UpdateAvailable {
+			outdated = append(outdated, status)
+		}
+	}
+
+	if *output == OutputText && len(outdated) == 0 {
+		fmt.Println(color.GreenString(i18n.T("update.allUpToDate")))
+		return ExitOK
+	}
+
+	return printStatuses(outdated, *output)
+}
+
+// fetchStatusVersions fetches version info, showing a spinner only for text output
+func (a *App) fetchStatusVersions(tools []config.Tool, output string) {
+	if output != OutputText {
+		a.fetchVersionsConcurrently(a.ctx, tools)
+		return
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("query.checking")
+	s.Start()
+	a.fetchVersionsConcurrently(a.ctx, tools)
+	s.Stop()
+}
+
+// printStatuses writes tool statuses in the requested output format
+func printStatuses(statuses []ToolStatus, output string) int {
+	if output != OutputText {
+		if err := writeStatusReport(os.Stdout, output, statuses); err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	for _, status := range statuses {
+		text := color.New(color.FgHiBlack).Sprint(i18n.T("cli.notInstalledStatus"))
+		if status.Installed {
+			if status.CurrentVersion == "" {
+				text = i18n.T("query.unknownVersion")
+			} else {
+				text = "v" + status.CurrentVersion
+			}
+
+			if status.UpdateAvailable {
+				text += color.YellowString(" " + i18n.T("query.updateAvailable", status.LatestVersion))
+			} else if status.Policy == config.PolicyPinned {
+				text += color.New(color.FgHiBlack).Sprint(" " + i18n.T("query.pinned"))
+			}
+		}
+
+		fmt.Printf("%s %s %s %s\n",
+			color.BlueString("•"),
+			color.New(color.Bold).Sprint(status.Name),
+			color.New(color.FgHiBlack).Sprintf("(%s)", status.Package),
+			text)
+	}
+
+	return ExitOK
+}
+
+// resolveTools maps command-line names to catalog tools, reporting unknown names
+func (a *App) resolveTools(names []string) ([]config.Tool, bool) {
+	var tools []config.Tool
+	seen := make(map[string]bool)
+	ok := true
+
+	for _, name := range names {
+		tool, found := a.config.FindTool(name)
+		if !found {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.unknownTool", name)))
+			ok = false
+			continue
+		}
+		if !seen[tool.Package] {
+			seen[tool.Package] = true
+			tools = append(tools, tool)
+		}
+	}
+
+	return tools, ok
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("atm "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parses flags that may appear before, between or after positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagExitCode maps a flag parsing error to an exit code; -h is not an error
+func flagExitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	return ExitUsage
+}
+
+// refreshFlag registers the --refresh flag, which bypasses the version cache
+func (a *App) refreshFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&a.refresh, "refresh", a.refresh, "bypass the version cache")
+}
+
+// outputFlag registers the --output/-o flag on a flag set
+func outputFlag(fs *flag.FlagSet) *string {
+	output := fs.String("output", OutputText, "output format: text, json or yaml")
+	fs.StringVar(output, "o", OutputText, "shorthand for --output")
+	return output
+}
+
+// startFailed prints why a command could not start and returns its exit code
+func startFailed(err error) int {
+	if errors.Is(err, ErrInterrupted) {
+		return reportNothingChanged()
+	}
+	fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+	return ExitFailure
+}
+
+// usageError prints the usage line for a command and returns ExitUsage
+func usageError(usageKey string) int {
+	fmt.Fprintln(os.Stderr, i18n.T("cli.usage.prefix")+" "+i18n.T(usageKey))
+	return ExitUsage
+}
@@ -0,0 +1,878 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+	"github.com/xiaoxu123195/atm/pkg/ui"
+)
+
+// dashboardTick is how often the dashboard animates running operations and
+// notices a resized terminal
+const dashboardTick = 150 * time.Millisecond
+
+// jobOutputLines is the number of output lines kept for each operation
+const jobOutputLines = 50
+
+// jobState is the progress of a dashboard operation
+type jobState int
+
+const (
+	jobQueued jobState = iota
+	jobRunning
+	jobDone
+	jobFailed
+	jobCancelled
+)
+
+// dashboardJob is a package operation queued from the dashboard. Jobs run one
+// at a time, since package managers lock their global directory.
+type dashboardJob struct {
+	title string
+	// pkg is the package the job changes; empty for refreshes
+	pkg string
+	// run performs the job with a context that is canceled with the job
+	// and sends the package manager's output to the operations pane
+	run func(ctx context.Context) error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	state    jobState
+	started  time.Time
+	finished time.Time
+	output   []string
+	err      error
+}
+
+// dashboardRow is a catalog tool as shown in the dashboard table
+type dashboardRow struct {
+	tool      config.Tool
+	installed bool
+}
+
+// Events sent to the dashboard loop by running jobs
+type (
+	jobOutput struct {
+		job  *dashboardJob
+		line string
+	}
+	jobFinished struct {
+		job  *dashboardJob
+		err  error
+		rows []dashboardRow
+	}
+)
+
+// dashboard is the state of the full-screen dashboard. Only its loop changes
+// it; the running job owns the tool lists of App until it finishes.
+type dashboard struct {
+	a      *App
+	screen *ui.Screen
+	// base is the context jobs are derived from; it is canceled on SIGINT
+	base   context.Context
+	events chan any
+	// done is closed when the loop returns, so that jobs stop sending events
+	done chan struct{}
+	// job tracks the goroutine of the running job
+	job sync.WaitGroup
+
+	rows   []dashboardRow
+	cursor int
+	top    int
+
+	jobs []*dashboardJob
+	// bins caches the executables shown in the details of installed tools
+	bins map[string][]string
+
+	details  bool
+	confirm  *config.Tool
+	message  string
+	quitting bool
+	frame    int
+}
+
+// cmdDashboard shows every catalog tool in a full-screen table with keys to
+// install, update and uninstall them
+func (a *App) cmdDashboard(args []string) int {
+	fs := newFlagSet("dashboard")
+	a.refreshFlag(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	screen, err := ui.NewScreen()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("dashboard.notTerminal")))
+		return ExitFailure
+	}
+
+	// A permission prompt cannot be answered on the dashboard; failures show the hint instead
+	a.prefixOffered = true
+
+	d := &dashboard{
+		a:      a,
+		screen: screen,
+		base:   a.ctx,
+		events: make(chan any),
+		done:   make(chan struct{}),
+		rows:   a.dashboardRows(),
+		bins:   make(map[string][]string),
+	}
+	d.enqueue(i18n.T("dashboard.job.check"), "", func(ctx context.Context) error {
+		a.fetchVersionsConcurrently(ctx, a.config.Tools)
+		return nil
+	})
+	d.loop()
+	close(d.done)
+	screen.Close()
+	// A job left running was canceled; it owns the tool lists until it returns
+	d.job.Wait()
+
+	return d.printSummary()
+}
+
+// dashboardRows lists the catalog tools with their installed state
+func (a *App) dashboardRows() []dashboardRow {
+	rows := make([]dashboardRow, len(a.config.Tools))
+	for i, tool := range a.config.Tools {
+		rows[i] = dashboardRow{tool: tool, installed: a.isInstalled(tool)}
+	}
+	return rows
+}
+
+// refreshTools re-reads the global store and revalidates the latest version
+// of every catalog tool, ignoring how fresh the cache is
+func (a *App) refreshTools(ctx context.Context) error {
+	err := a.loadInstalledTools(ctx)
+
+	var wg sync.WaitGroup
+	for _, tool := range a.config.Tools {
+		wg.Add(1)
+		go func(t config.Tool) {
+			defer wg.Done()
+			a.semaphore <- struct{}{}        // Acquire
+			defer func() { <-a.semaphore }() // Release
+
+			a.fetchLatestVersion(ctx, t)
+		}(tool)
+	}
+	wg.Wait()
+	return err
+}
+
+// loop draws the dashboard and handles keys and job events until the user quits
+func (d *dashboard) loop() {
+	ticker := time.NewTicker(dashboardTick)
+	defer ticker.Stop()
+
+	width, height := d.screen.Size()
+	interrupted := d.base.Done()
+	redraw := true
+	for {
+		if d.quitting && d.running() == nil {
+			return
+		}
+		if redraw {
+			d.draw()
+		}
+
+		redraw = true
+		select {
+		case k, ok := <-d.screen.Keys():
+			if !ok || d.handleKey(k) {
+				return
+			}
+		case ev := <-d.events:
+			d.handleEvent(ev)
+		case <-interrupted:
+			interrupted = nil
+			d.cancelAll()
+			d.quitting = true
+		case <-ticker.C:
+			d.frame++
+			// Redraw an idle dashboard only when the terminal was resized,
+			// which keeps it quiet over slow connections
+			w, h := d.screen.Size()
+			redraw = d.running() != nil || w != width || h != height
+			width, height = w, h
+		}
+	}
+}
+
+// handleKey handles a key press and reports whether the dashboard should close
+func (d *dashboard) handleKey(k ui.Key) bool {
+	d.message = ""
+
+	if k.Code == ui.KeyCtrlC {
+		if d.running() == nil {
+			return true
+		}
+		if d.quitting {
+			// Pressed twice: close the screen without waiting for the canceled job
+			return true
+		}
+		d.cancelAll()
+		d.quitting = true
+		d.message = i18n.T("dashboard.cancelling")
+		return false
+	}
+
+	if d.confirm != nil {
+		tool := *d.confirm
+		d.confirm = nil
+		if k.Rune == 'y' || k.Rune == 'Y' {
+			d.enqueueTool(i18n.T("dashboard.job.uninstall", tool.Name), tool, d.a.uninstallTool)
+		}
+		return false
+	}
+
+	switch {
+	case k.Code == ui.KeyUp || k.Rune == 'k':
+		d.move(-1)
+	case k.Code == ui.KeyDown || k.Rune == 'j':
+		d.move(1)
+	case k.Code == ui.KeyPageUp:
+		d.move(-d.tableHeight())
+	case k.Code == ui.KeyPageDown:
+		d.move(d.tableHeight())
+	case k.Code == ui.KeyHome:
+		d.move(-len(d.rows))
+	case k.Code == ui.KeyEnd:
+		d.move(len(d.rows))
+	case k.Code == ui.KeyEnter || k.Rune == 'v':
+		d.details = !d.details
+	case k.Code == ui.KeyEsc:
+		d.details = false
+	case k.Rune == 'i':
+		d.install()
+	case k.Rune == 'u':
+		d.update()
+	case k.Rune == 'U':
+		d.updateAll()
+	case k.Rune == 'd' || k.Rune == 'x':
+		d.uninstall()
+	case k.Rune == 'r':
+		if d.pending("") {
+			return false
+		}
+		d.enqueue(i18n.T("dashboard.job.refresh"), "", d.a.refreshTools)
+	case k.Rune == 'c':
+		d.clearFinished()
+	case k.Rune == 'q':
+		if d.running() != nil {
+			d.message = i18n.T("dashboard.busy")
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// move moves the cursor by delta rows
+func (d *dashboard) move(delta int) {
+	d.cursor = min(max(d.cursor+delta, 0), len(d.rows)-1)
+}
+
+// selected returns the row under the cursor
+func (d *dashboard) selected() (dashboardRow, bool) {
+	if len(d.rows) == 0 {
+		return dashboardRow{}, false
+	}
+	return d.rows[d.cursor], true
+}
+
+// install queues the installation of the selected tool
+func (d *dashboard) install() {
+	row, ok := d.selected()
+	switch {
+	case !ok:
+	case row.installed:
+		d.message = i18n.T("cli.alreadyInstalled", row.tool.Name)
+	case d.pending(row.tool.Package):
+		d.message = i18n.T("dashboard.queued", row.tool.Name)
+	default:
+		d.enqueueTool(i18n.T("dashboard.job.install", row.tool.Name), row.tool, d.a.installTool)
+	}
+}
+
+// update queues the update of the selected tool
+func (d *dashboard) update() {
+	row, ok := d.selected()
+	if !ok {
+		return
+	}
+	versionInfo, _ := d.a.versionCache.Get(row.tool.Package)
+	switch {
+	case !row.installed:
+		d.message = i18n.T("cli.notInstalled", row.tool.Name)
+	case row.tool.UpdatePolicy() == config.PolicyPinned:
+		d.message = i18n.T("cli.pinned", row.tool.Name)
+	case d.pending(row.tool.Package):
+		d.message = i18n.T("dashboard.queued", row.tool.Name)
+	case !hasUpdate(versionInfo):
+		d.message = i18n.T("cli.upToDate", row.tool.Name, versionInfo.CurrentVersion)
+	default:
+		d.enqueueUpdate(row.tool)
+	}
+}
+
+// updateAll queues the update of every tool with an update, like update --all
+func (d *dashboard) updateAll() {
+	queued := 0
+	for _, row := range d.rows {
+		versionInfo, _ := d.a.versionCache.Get(row.tool.Package)
+		if !row.installed || !updateOffered(row.tool, versionInfo) ||
+			row.tool.UpdatePolicy() == config.PolicyNotify || d.pending(row.tool.Package) {
+			continue
+		}
+		d.enqueueUpdate(row.tool)
+		queued++
+	}
+	if queued == 0 {
+		d.message = i18n.T("update.allUpToDate")
+	}
+}
+
+// enqueueUpdate queues the update of a tool
+func (d *dashboard) enqueueUpdate(tool config.Tool) {
+	d.enqueueTool(i18n.T("dashboard.job.update", tool.Name), tool, d.a.updateTool)
+}
+
+// uninstall asks to confirm the uninstallation of the selected tool
+func (d *dashboard) uninstall() {
+	row, ok := d.selected()
+	switch {
+	case !ok:
+	case !row.installed:
+		d.message = i18n.T("cli.notInstalled", row.tool.Name)
+	case d.pending(row.tool.Package):
+		d.message = i18n.T("dashboard.queued", row.tool.Name)
+	default:
+		tool := row.tool
+		d.confirm = &tool
+	}
+}
+
+// enqueueTool queues an operation on a tool. Afterwards the latest version of
+// the tool is fetched again, since the operation dropped it from the cache.
+func (d *dashboard) enqueueTool(title string, tool config.Tool, operation func(context.Context, config.Tool) error) {
+	d.enqueue(title, tool.Package, func(ctx context.Context) error {
+		err := operation(ctx, tool)
+		if !errors.Is(err, context.Canceled) {
+			d.a.fetchLatestVersion(ctx, tool)
+		}
+		return err
+	})
+}
+
+// enqueue adds a job to the queue and starts it if nothing is running
+func (d *dashboard) enqueue(title, pkg string, run func(context.Context) error) {
+	ctx, cancel := context.WithCancel(d.base)
+	d.jobs = append(d.jobs, &dashboardJob{title: title, pkg: pkg, run: run, ctx: ctx, cancel: cancel})
+	d.startNext()
+}
+
+// startNext starts the first queued job unless a job is running
+func (d *dashboard) startNext() {
+	if d.running() != nil {
+		return
+	}
+	for _, job := range d.jobs {
+		if job.state != jobQueued {
+			continue
+		}
+
+		job.state = jobRunning
+		job.started = time.Now()
+		ctx := manager.WithOutput(job.ctx, &jobWriter{job: job, send: d.send})
+		d.job.Add(1)
+		go func(job *dashboardJob) {
+			defer d.job.Done()
+			err := job.run(ctx)
+			d.send(jobFinished{job: job, err: err, rows: d.a.dashboardRows()})
+		}(job)
+		return
+	}
+}
+
+// handleEvent applies an event sent by the running job
+func (d *dashboard) handleEvent(ev any) {
+	switch ev := ev.(type) {
+	case jobOutput:
+		ev.job.output = append(ev.job.output, ev.line)
+		if len(ev.job.output) > jobOutputLines {
+			ev.job.output = ev.job.output[len(ev.job.output)-jobOutputLines:]
+		}
+	case jobFinished:
+		job := ev.job
+		job.cancel()
+		job.finished = time.Now()
+		job.err = ev.err
+		switch {
+		case errors.Is(ev.err, context.Canceled):
+			job.state = jobCancelled
+		case ev.err != nil:
+			job.state = jobFailed
+		default:
+			job.state = jobDone
+		}
+		d.rows = ev.rows
+		d.bins = make(map[string][]string)
+		if !d.quitting {
+			d.startNext()
+		}
+	}
+}
+
+// send passes an event from the running job to the loop, dropping it once
+// the loop has returned
+func (d *dashboard) send(ev any) {
+	select {
+	case d.events <- ev:
+	case <-d.done:
+	}
+}
+
+// running returns the running job, if any
+func (d *dashboard) running() *dashboardJob {
+	for _, job := range d.jobs {
+		if job.state == jobRunning {
+			return job
+		}
+	}
+	return nil
+}
+
+// pending reports whether a job for a package is queued or running
+func (d *dashboard) pending(pkg string) bool {
+	for _, job := range d.jobs {
+		if job.pkg == pkg && (job.state == jobQueued || job.state == jobRunning) {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelAll cancels the running job and drops the queued ones
+func (d *dashboard) cancelAll() {
+	for _, job := range d.jobs {
+		switch job.state {
+		case jobRunning:
+			job.cancel()
+		case jobQueued:
+			job.cancel()
+			job.state = jobCancelled
+		}
+	}
+}
+
+// clearFinished removes finished jobs from the operations pane
+func (d *dashboard) clearFinished() {
+	var jobs []*dashboardJob
+	for _, job := range d.jobs {
+		if job.state == jobQueued || job.state == jobRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	d.jobs = jobs
+}
+
+// printSummary prints the result of every operation after the dashboard
+// closed, so that it stays in the terminal history, and returns the exit code
+func (d *dashboard) printSummary() int {
+	code := ExitOK
+	for _, job := range d.jobs {
+		switch job.state {
+		case jobDone:
+			if job.pkg != "" {
+				fmt.Println(color.GreenString("✓ " + job.title))
+			}
+		case jobFailed:
+			fmt.Fprintln(os.Stderr, color.RedString("✗ "+job.title+": "+d.a.describeError(job.err)))
+			printHint(os.Stderr, job.err)
+			code = failureCode(code, job.err)
+		case jobRunning, jobCancelled:
+			if job.pkg != "" {
+				fmt.Fprintln(os.Stderr, color.YellowString("⚠ "+job.title+": "+i18n.T("dashboard.cancelled")))
+			}
+			code = ExitInterrupted
+		}
+	}
+	return code
+}
+
+// tableHeight returns the number of table rows that fit on the screen
+func (d *dashboard) tableHeight() int {
+	_, height := d.screen.Size()
+	// Title, column headers and footer
+	return max(height-3-d.paneHeight(), 1)
+}
+
+// paneHeight returns the height of the operations pane
+func (d *dashboard) paneHeight() int {
+	if len(d.jobs) == 0 {
+		return 0
+	}
+	_, height := d.screen.Size()
+	return min(max(height/3, 4), 12)
+}
+
+// draw renders the whole dashboard
+func (d *dashboard) draw() {
+	width, _ := d.screen.Size()
+	var lines []string
+
+	// Title bar
+	installed, updates := 0, 0
+	for _, row := range d.rows {
+		if row.installed {
+			installed++
+			if versionInfo, _ := d.a.versionCache.Get(row.tool.Package); updateOffered(row.tool, versionInfo) {
+				updates++
+			}
+		}
+	}
+	title := " " + i18n.T("dashboard.title", d.a.version, d.a.packageManager.Backend().Name()) +
+		"  ·  " + i18n.T("dashboard.summary", installed, updates)
+	lines = append(lines, color.New(color.ReverseVideo).Sprint(ui.Pad(title, width)))
+
+	if d.details {
+		lines = append(lines, d.detailLines(width, d.tableHeight()+1)...)
+	} else {
+		lines = append(lines, d.tableLines(width)...)
+	}
+	lines = append(lines, d.paneLines(width)...)
+
+	// Footer
+	footer := color.New(color.FgHiBlack).Sprint(ui.Truncate(i18n.T("dashboard.help"), width-1))
+	switch {
+	case d.confirm != nil:
+		footer = color.YellowString(ui.Truncate(i18n.T("dashboard.confirmUninstall", d.confirm.Name), width-1))
+	case d.message != "":
+		footer = color.YellowString(ui.Truncate(d.message, width-1))
+	case d.details:
+		footer = color.New(color.FgHiBlack).Sprint(ui.Truncate(i18n.T("dashboard.detailsHelp"), width-1))
+	}
+	lines = append(lines, footer)
+
+	d.screen.Draw(lines)
+}
+
+// tableLines renders the column headers and the visible rows, padded to the
+// height of the table
+func (d *dashboard) tableLines(width int) []string {
+	height := d.tableHeight()
+
+	headers := []string{
+		i18n.T("dashboard.colTool"), i18n.T("dashboard.colPackage"), i18n.T("dashboard.colStatus"),
+		i18n.T("dashboard.colCurrent"), i18n.T("dashboard.colLatest"),
+	}
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = ui.Width(header)
+	}
+	cells := make([][]string, len(d.rows))
+	for r, row := range d.rows {
+		cells[r] = d.rowCells(row)
+		for i := range headers {
+			widths[i] = max(widths[i], ui.Width(cells[r][i]))
+		}
+	}
+	// Long package names give way first, then long tool names
+	widths[0], widths[1] = min(widths[0], 24), min(widths[1], 36)
+	for _, i := range []int{1, 0} {
+		if excess := sum(widths) + 2*len(widths) - width*2/3; excess > 0 {
+			widths[i] = max(widths[i]-excess, 8)
+		}
+	}
+
+	join := func(values []string) string {
+		var b strings.Builder
+		for i, value := range values {
+			b.WriteString(ui.Pad(value, widths[i]))
+			b.WriteString("  ")
+		}
+		return b.String()
+	}
+
+	lines := []string{color.New(color.Bold).Sprint(ui.Pad("  "+join(headers), width))}
+
+	// Scroll so that the cursor stays on screen
+	if d.cursor < d.top {
+		d.top = d.cursor
+	} else if d.cursor >= d.top+height {
+		d.top = d.cursor - height + 1
+	}
+	gray := color.New(color.FgHiBlack)
+	for r := d.top; r < min(d.top+height, len(d.rows)); r++ {
+		row := d.rows[r]
+		text := join(cells[r])
+		badge, badgeColor := d.badge(row)
+		if r == d.cursor {
+			line := ui.Pad("▸ "+text+badge, width)
+			lines = append(lines, color.New(color.ReverseVideo).Sprint(line))
+			continue
+		}
+
+		// Narrow terminals get the row without colors, cut to fit
+		if ui.Width(text) > width-3 {
+			lines = append(lines, "  "+ui.Truncate(text, width-3))
+			continue
+		}
+		statusColor := gray
+		if row.installed {
+			statusColor = color.New(color.FgGreen)
+		}
+		var b strings.Builder
+		b.WriteString("  ")
+		for i, value := range cells[r] {
+			cell := ui.Pad(value, widths[i]) + "  "
+			if i == 2 {
+				cell = statusColor.Sprint(cell)
+			}
+			b.WriteString(cell)
+		}
+		b.WriteString(badgeColor.Sprint(ui.Truncate(badge, width-3-ui.Width(text))))
+		lines = append(lines, b.String())
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// rowCells returns the name, package, status and versions of a row
+func (d *dashboard) rowCells(row dashboardRow) []string {
+	versionInfo, _ := d.a.versionCache.Get(row.tool.Package)
+	status, current := i18n.T("dashboard.notInstalled"), "-"
+	if row.installed {
+		status = i18n.T("dashboard.installed")
+		if versionInfo.CurrentVersion != "" {
+			current = versionInfo.CurrentVersion
+		}
+	}
+	latest := "-"
+	if versionInfo.LatestVersion != "" {
+		latest = versionInfo.LatestVersion
+	}
+	return []string{row.tool.Name, row.tool.Package, status, current, latest}
+}
+
+// badge returns the note shown after a row and its color: the state of its
+// operation, an available update or its pin
+func (d *dashboard) badge(row dashboardRow) (string, *color.Color) {
+	for i := len(d.jobs) - 1; i >= 0; i-- {
+		job := d.jobs[i]
+		if job.pkg != row.tool.Package {
+			continue
+		}
+		switch job.state {
+		case jobQueued:
+			return "… " + i18n.T("dashboard.queuedBadge"), color.New(color.FgCyan)
+		case jobRunning:
+			return d.spinnerFrame() + " " + job.title, color.New(color.FgCyan)
+		}
+		break
+	}
+
+	if !row.installed {
+		return "", color.New(color.Reset)
+	}
+	if row.tool.UpdatePolicy() == config.PolicyPinned {
+		return i18n.T("dashboard.pinnedBadge"), color.New(color.FgHiBlack)
+	}
+	if versionInfo, _ := d.a.versionCache.Get(row.tool.Package); hasUpdate(versionInfo) {
+		return "↑ " + i18n.T("dashboard.updateBadge"), color.New(color.FgYellow)
+	}
+	return "", color.New(color.Reset)
+}
+
+// spinnerFrame returns the current frame of the running job's spinner
+func (d *dashboard) spinnerFrame() string {
+	frames := spinner.CharSets[14]
+	return frames[d.frame%len(frames)]
+}
+
+// detailLines renders the details of the selected tool, padded to height
+func (d *dashboard) detailLines(width, height int) []string {
+	row, ok := d.selected()
+	if !ok {
+		return make([]string, height)
+	}
+	tool := row.tool
+	cells := d.rowCells(row)
+	gray := color.New(color.FgHiBlack)
+
+	constraint, policy := tool.Version, tool.UpdatePolicy()
+	if constraint == "" {
+		constraint = "-"
+	}
+
+	fields := [][2]string{
+		{i18n.T("dashboard.detail.package"), tool.Package},
+		{i18n.T("dashboard.detail.source"), tool.SourceName()},
+		{i18n.T("dashboard.detail.description"), tool.Description},
+		{i18n.T("dashboard.detail.status"), cells[2]},
+		{i18n.T("dashboard.detail.current"), cells[3]},
+		{i18n.T("dashboard.detail.latest"), cells[4]},
+		{i18n.T("dashboard.detail.constraint"), constraint},
+		{i18n.T("dashboard.detail.policy"), policy},
+	}
+	if bins := d.packageBins(row); len(bins) > 0 {
+		fields = append(fields, [2]string{i18n.T("dashboard.detail.bins"), strings.Join(bins, ", ")})
+	}
+
+	labelWidth := 0
+	for _, field := range fields {
+		labelWidth = max(labelWidth, ui.Width(field[0]))
+	}
+
+	lines := []string{"", "  " + color.New(color.Bold).Sprint(ui.Truncate(tool.Name, width-3)), ""}
+	for _, field := range fields {
+		value := ui.Truncate(field[1], width-labelWidth-5)
+		lines = append(lines, "  "+gray.Sprint(ui.Pad(field[0], labelWidth))+"  "+value)
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines[:height]
+}
+
+// packageBins returns the executables of a tool installed from npm. They are
+// read once per tool until a job finishes.
+func (d *dashboard) packageBins(row dashboardRow) []string {
+	// Only npm packages declare their executables
+	if !row.installed || !row.tool.IsNpm() {
+		return nil
+	}
+	if bins, ok := d.bins[row.tool.Package]; ok {
+		return bins
+	}
+
+	var bins []string
+	if root, err := d.a.packageManager.Backend().GlobalRoot(d.base); err == nil {
+		bins, _ = manager.PackageBins(root, row.tool.Package)
+	}
+	d.bins[row.tool.Package] = bins
+	return bins
+}
+
+// paneLines renders the operations pane: the recent jobs and the output of
+// the running one
+func (d *dashboard) paneLines(width int) []string {
+	height := d.paneHeight()
+	if height == 0 {
+		return nil
+	}
+	gray := color.New(color.FgHiBlack)
+
+	var lines []string
+	for _, job := range d.jobs {
+		switch job.state {
+		case jobQueued:
+			lines = append(lines, gray.Sprint(ui.Truncate("  … "+job.title, width-1)))
+		case jobRunning:
+			elapsed := time.Since(job.started).Round(time.Second)
+			lines = append(lines, color.CyanString(ui.Truncate(fmt.Sprintf("%s %s (%s)", d.spinnerFrame(), job.title, elapsed), width-1)))
+			for _, line := range job.output {
+				lines = append(lines, gray.Sprint(ui.Truncate("    "+line, width-1)))
+			}
+		case jobDone:
+			elapsed := job.finished.Sub(job.started).Round(time.Second)
+			lines = append(lines, color.GreenString(ui.Truncate(fmt.Sprintf("✓ %s (%s)", job.title, elapsed), width-1)))
+		case jobFailed:
+			lines = append(lines, color.RedString(ui.Truncate("✗ "+job.title+": "+d.a.describeError(job.err), width-1)))
+			if h := hint(job.err); h != "" {
+				lines = append(lines, gray.Sprint(ui.Truncate("  "+h, width-1)))
+			}
+		case jobCancelled:
+			lines = append(lines, color.YellowString(ui.Truncate("⚠ "+job.title+": "+i18n.T("dashboard.cancelled"), width-1)))
+		}
+	}
+
+	// Keep the newest lines, under a separator
+	if len(lines) > height-1 {
+		lines = lines[len(lines)-(height-1):]
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	separator := "── " + i18n.T("dashboard.operations") + " "
+	separator += strings.Repeat("─", max(width-1-ui.Width(separator), 0))
+	return append([]string{gray.Sprint(ui.Truncate(separator, width-1))}, lines...)
+}
+
+// sum adds up column widths
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// jobWriter splits the output of a job's package manager into lines and
+// sends them to the dashboard loop
+type jobWriter struct {
+	job  *dashboardJob
+	send func(any)
+
+	// mu serializes stdout and stderr, which are copied concurrently
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *jobWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		// npm redraws progress lines with \r
+		i := bytes.IndexAny(w.partial, "\r\n")
+		if i < 0 {
+			break
+		}
+		line := printable(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+		if line != "" {
+			w.send(jobOutput{job: w.job, line: line})
+		}
+	}
+	return len(p), nil
+}
+
+// escapeSequence matches the CSI (ESC [ ... final byte) and OSC (ESC ] ...
+// BEL or ESC \) sequences terminals interpret
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// printable removes escape sequences and control characters from a line of
+// output, so that it cannot move the cursor or clear the screen
+func printable(line string) string {
+	line = escapeSequence.ReplaceAllString(line, "")
+	line = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, line)
+	return strings.TrimSpace(line)
+}
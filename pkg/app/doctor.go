@@ -0,0 +1,455 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+)
+
+// minNodeMajor is the oldest Node.js major version atm supports
+const minNodeMajor = 18
+
+// pingTimeout bounds the registry reachability check
+const pingTimeout = 10 * time.Second
+
+// checkStatus is the outcome of a doctor check
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// checkResult is one line of the doctor report
+type checkResult struct {
+	Status checkStatus
+	Label  string
+	Detail string
+	Hint   string
+}
+
+// cmdDoctor checks the environment atm depends on and prints a pass, warn or
+// fail line for each check, with a hint on how to fix problems
+func (a *App) cmdDoctor(args []string) int {
+	fs := newFlagSet("doctor")
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	backend := a.packageManager.Backend()
+	globalRoot, rootErr := backend.GlobalRoot(a.ctx)
+	globalBin, binErr := backend.GlobalBin(a.ctx)
+
+	results := []checkResult{checkNode(a.ctx)}
+	results = append(results, checkPackageManagers(a.ctx, backend.Name())...)
+	results = append(results, checkPrefix(globalRoot, rootErr), checkBinOnPath(globalBin, binErr))
+	results = append(results, checkRegistry(a.ctx, a.packageManager.Registry()), checkProxy(a.packageManager.Registry()))
+	results = append(results, a.checkSources()...)
+	if rootErr == nil {
+		results = append(results, a.checkToolBinaries(globalRoot, globalBin)...)
+	}
+
+	var passed, warnings, failed int
+	for _, result := range results {
+		printCheck(result)
+		switch result.Status {
+		case checkPass:
+			passed++
+		case checkWarn:
+			warnings++
+		case checkFail:
+			failed++
+		}
+	}
+
+	fmt.Println()
+	summary := i18n.T("doctor.summary", passed, warnings, failed)
+	switch {
+	case failed > 0:
+		fmt.Println(color.RedString(summary))
+		return ExitFailure
+	case warnings > 0:
+		fmt.Println(color.YellowString(summary))
+	default:
+		fmt.Println(color.GreenString(summary))
+	}
+	return ExitOK
+}
+
+// printCheck prints a check result and its hint
+func printCheck(result checkResult) {
+	switch result.Status {
+	case checkPass:
+		fmt.Printf("%s %s: %s\n", color.GreenString("✓"), result.Label, result.Detail)
+	case checkWarn:
+		fmt.Printf("%s %s: %s\n", color.YellowString("⚠"), result.Label, result.Detail)
+	case checkFail:
+		fmt.Printf("%s %s: %s\n", color.RedString("✗"), result.Label, result.Detail)
+	}
+	if result.Hint != "" && result.Status != checkPass {
+		fmt.Println(color.New(color.FgHiBlack).Sprint("  " + i18n.T("errors.hint", result.Hint)))
+	}
+}
+
+// checkNode checks that Node.js is installed and recent enough
+func checkNode(ctx context.Context) checkResult {
+	result := checkCommand(ctx, "node", checkFail, i18n.T("doctor.hint.installNode", minNodeMajor))
+	if result.Status != checkPass {
+		return result
+	}
+
+	if version, err := semver.Parse(result.Detail); err == nil && version.Major < minNodeMajor {
+		result.Status = checkWarn
+		result.Detail = i18n.T("doctor.nodeTooOld", result.Detail, minNodeMajor)
+		result.Hint = i18n.T("doctor.hint.upgradeNode", minNodeMajor)
+	}
+	return result
+}
+
+// checkPackageManagers checks npm and, when another backend is in use, that
+// backend. npm is only required when it is the backend.
+func checkPackageManagers(ctx context.Context, backend string) []checkResult {
+	if backend == "npm" {
+		return []checkResult{checkCommand(ctx, "npm", checkFail, i18n.T("doctor.hint.installNpm"))}
+	}
+	return []checkResult{
+		checkCommand(ctx, "npm", checkWarn, i18n.T("doctor.hint.installNpm")),
+		checkCommand(ctx, backend, checkFail, i18n.T("doctor.hint.installBackend", backend)),
+	}
+}
+
+// checkCommand checks that a program is on PATH and reports its version.
+// A missing program gets the given status and hint.
+func checkCommand(ctx context.Context, name string, missing checkStatus, hint string) checkResult {
+	result := checkResult{Label: name}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		result.Status = missing
+		result.Detail = i18n.T("doctor.notFound")
+		result.Hint = hint
+		return result
+	}
+
+	version, err := manager.CommandVersion(ctx, name)
+	if err != nil {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.versionFailed", path, err.Error())
+		result.Hint = i18n.T("doctor.hint.reinstallProgram", name)
+		return result
+	}
+
+	result.Detail = version
+	return result
+}
+
+// checkPrefix checks that the global package directory can be written
+// without elevated privileges
+func checkPrefix(globalRoot string, rootErr error) checkResult {
+	result := checkResult{Label: i18n.T("doctor.label.prefix")}
+	if rootErr != nil {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.lookupFailed", rootErr.Error())
+		result.Hint = i18n.T("doctor.hint.lookup")
+		return result
+	}
+
+	// The directory is created by the first global install, so test the
+	// nearest directory that already exists
+	dir := globalRoot
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".atm-doctor-*")
+	if err != nil {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.prefixReadOnly", globalRoot)
+		result.Hint = i18n.T("doctor.hint.prefix")
+		return result
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	result.Detail = i18n.T("doctor.prefixWritable", globalRoot)
+	return result
+}
+
+// checkBinOnPath checks that the directory global executables are linked
+// into is on PATH
+func checkBinOnPath(globalBin string, binErr error) checkResult {
+	result := checkResult{Label: i18n.T("doctor.label.bin")}
+	if binErr != nil {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.lookupFailed", binErr.Error())
+		result.Hint = i18n.T("doctor.hint.lookup")
+		return result
+	}
+
+	if !onPath(globalBin) {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.notOnPath", globalBin)
+		result.Hint = i18n.T("doctor.hint.addToPath", globalBin)
+		return result
+	}
+
+	result.Detail = i18n.T("doctor.onPath", globalBin)
+	return result
+}
+
+// checkRegistry checks that the default registry answers
+func checkRegistry(ctx context.Context, registry *manager.RegistryClient) checkResult {
+	result := checkResult{Label: i18n.T("doctor.label.registry")}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	status, err := registry.Ping(ctx)
+	switch {
+	case err != nil:
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.registryUnreachable", registry.Registry, err.Error())
+		result.Hint = i18n.T("doctor.hint.network")
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		result.Status = checkWarn
+		result.Detail = i18n.T("doctor.registryStatus", registry.Registry, status)
+		result.Hint = i18n.T("doctor.hint.auth")
+	case status >= http.StatusInternalServerError:
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.registryStatus", registry.Registry, status)
+		result.Hint = i18n.T("doctor.hint.registryError")
+	default:
+		// Some mirrors do not implement the ping endpoint; any answer below
+		// 500 means the registry is reachable
+		result.Detail = i18n.T("doctor.registryReachable", registry.Registry)
+	}
+	return result
+}
+
+// checkProxy reports the proxy settings and checks that atm, which honors only
+// the HTTP(S)_PROXY variables, goes through the same proxy as npm
+func checkProxy(registry *manager.RegistryClient) checkResult {
+	result := checkResult{Label: i18n.T("doctor.label.proxy")}
+
+	envName, envProxy := proxyFromEnv()
+	npmProxy := registry.HTTPSProxy
+	if npmProxy == "" {
+		npmProxy = registry.Proxy
+	}
+
+	for _, proxy := range []string{envProxy, npmProxy} {
+		if proxy == "" {
+			continue
+		}
+		if _, err := parseProxy(proxy); err != nil {
+			result.Status = checkFail
+			result.Detail = i18n.T("doctor.proxyInvalid", redactProxy(proxy))
+			result.Hint = i18n.T("doctor.hint.proxyInvalid")
+			return result
+		}
+	}
+
+	switch {
+	case envProxy == "" && npmProxy == "":
+		result.Detail = i18n.T("doctor.proxyNone")
+	case envProxy == "":
+		result.Status = checkWarn
+		result.Detail = i18n.T("doctor.proxyNpmOnly", redactProxy(npmProxy))
+		result.Hint = i18n.T("doctor.hint.proxyEnv")
+	default:
+		result.Detail = fmt.Sprintf("%s=%s", envName, redactProxy(envProxy))
+		if noProxy := firstEnv("NO_PROXY", "no_proxy"); noProxy != "" {
+			result.Detail += ", NO_PROXY=" + noProxy
+		}
+	}
+	return result
+}
+
+// checkSources checks the programs needed by the package sources other than
+// npm that the catalog uses, and that the directory binaries from GitHub
+// releases are installed into is on PATH
+func (a *App) checkSources() []checkResult {
+	used := make(map[string]bool)
+	for _, tool := range a.config.Tools {
+		used[tool.SourceName()] = true
+	}
+
+	var results []checkResult
+	for _, source := range config.SourceNames {
+		if source == config.SourceNpm || !used[source] {
+			continue
+		}
+
+		installer, err := a.packageManager.Installer(source)
+		if err != nil {
+			continue
+		}
+		if command := installer.Command(); command != "" {
+			results = append(results, checkCommand(a.ctx, command, checkWarn, i18n.T("doctor.hint.installSource", command, source)))
+		}
+		if source == config.SourceGitHubRelease {
+			results = append(results, checkReleaseBin())
+		}
+	}
+	return results
+}
+
+// checkReleaseBin checks that the directory binaries from GitHub releases are
+// installed into is on PATH
+func checkReleaseBin() checkResult {
+	result := checkResult{Label: i18n.T("doctor.label.releaseBin")}
+	dir, err := config.BinDir()
+	if err != nil {
+		result.Status = checkFail
+		result.Detail = i18n.T("doctor.lookupFailed", err.Error())
+		return result
+	}
+
+	if !onPath(dir) {
+		result.Status = checkWarn
+		result.Detail = i18n.T("doctor.notOnPath", dir)
+		result.Hint = i18n.T("doctor.hint.addToPath", dir)
+		return result
+	}
+
+	result.Detail = i18n.T("doctor.onPath", dir)
+	return result
+}
+
+// checkToolBinaries checks that every executable of each installed npm tool
+// resolves on PATH to the copy in the global bin directory
+func (a *App) checkToolBinaries(globalRoot, globalBin string) []checkResult {
+	var results []checkResult
+	for _, tool := range a.installedTools {
+		if !tool.IsNpm() {
+			continue
+		}
+		bins, err := manager.PackageBins(globalRoot, tool.Package)
+		if err != nil {
+			results = append(results, checkResult{
+				Status: checkWarn,
+				Label:  tool.Name,
+				Detail: i18n.T("doctor.manifestError", err.Error()),
+				Hint:   i18n.T("doctor.hint.reinstall", tool.Name),
+			})
+			continue
+		}
+
+		for _, bin := range bins {
+			result := checkResult{Label: fmt.Sprintf("%s (%s)", tool.Name, bin)}
+
+			path, err := exec.LookPath(bin)
+			switch {
+			case err != nil:
+				result.Status = checkFail
+				result.Detail = i18n.T("doctor.binMissing")
+				result.Hint = i18n.T("doctor.hint.binMissing", tool.Name)
+			case globalBin != "" && !samePath(filepath.Dir(path), globalBin):
+				result.Status = checkWarn
+				result.Detail = i18n.T("doctor.binShadowed", path, globalBin)
+				result.Hint = i18n.T("doctor.hint.binShadowed", globalBin)
+			default:
+				result.Detail = path
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// onPath reports whether dir is one of the directories on PATH
+func onPath(dir string) bool {
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry != "" && samePath(entry, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePath reports whether two paths name the same directory, ignoring case
+// on Windows and following symlinks such as /bin -> /usr/bin
+func samePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if a == b || runtime.GOOS == "windows" && strings.EqualFold(a, b) {
+		return true
+	}
+
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// proxyFromEnv returns the proxy variable Go's HTTP client uses for registry
+// requests, and its value
+func proxyFromEnv() (string, string) {
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if value := os.Getenv(name); value != "" {
+			return name, value
+		}
+	}
+	return "", ""
+}
+
+// firstEnv returns the value of the first set environment variable
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseProxy parses a proxy URL as net/http does: a proxy without a scheme,
+// such as proxy.corp:8080, is read as an http:// URL
+func parseProxy(proxy string) (*url.URL, error) {
+	if u, err := url.Parse(proxy); err == nil && u.Scheme != "" && u.Host != "" {
+		return u, nil
+	}
+	if strings.Contains(proxy, "://") {
+		return nil, fmt.Errorf("invalid proxy URL %q", proxy)
+	}
+	u, err := url.Parse("http://" + proxy)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", proxy)
+	}
+	return u, nil
+}
+
+// redactProxy hides the password in a proxy URL. The credentials of a proxy
+// that cannot be parsed are hidden whole.
+func redactProxy(proxy string) string {
+	u, err := parseProxy(proxy)
+	if err != nil {
+		if i := strings.LastIndex(proxy, "@"); i >= 0 {
+			return "xxxxx" + proxy[i:]
+		}
+		return proxy
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+)
+
+// failureKinds maps each kind of failure to its exit code and the i18n keys
+// of its message ("errors.<key>") and hint ("errors.hint.<key>"). Interrupted
+// operations come first, since an interrupted request can also look like a
+// network failure.
+var failureKinds = []struct {
+	err  error
+	code int
+	key  string
+}{
+	{context.Canceled, ExitInterrupted, "interrupted"},
+	{context.DeadlineExceeded, ExitFailure, "timeout"},
+	{manager.ErrNpmMissing, ExitNpmMissing, "npmMissing"},
+	{manager.ErrPermission, ExitPermission, "permission"},
+	{manager.ErrEngine, ExitEngine, "engine"},
+	{manager.ErrNetwork, ExitNetwork, "network"},
+	{manager.ErrNotFound, ExitNotFound, "notFound"},
+	{github.ErrChecksumMismatch, ExitFailure, "checksum"},
+	{github.ErrNoChecksum, ExitFailure, "noChecksum"},
+}
+
+// exitCode returns the exit code for a failed operation
+func exitCode(err error) int {
+	for _, kind := range failureKinds {
+		if errors.Is(err, kind.err) {
+			return kind.code
+		}
+	}
+	return ExitFailure
+}
+
+// failureCode returns the exit code after an operation failed. The first
+// failure of a command decides its exit code.
+func failureCode(code int, err error) int {
+	if code != ExitOK {
+		return code
+	}
+	return exitCode(err)
+}
+
+// describeError returns a localized description of a failed operation, with
+// the package manager's error code. Unrecognized failures keep their message.
+func (a *App) describeError(err error) string {
+	for _, kind := range failureKinds {
+		if !errors.Is(err, kind.err) {
+			continue
+		}
+
+		message := i18n.T("errors." + kind.key)
+		if kind.err == manager.ErrNpmMissing {
+			name := a.packageManager.Backend().Name()
+			if command := missingCommand(err); command != "" {
+				name = command
+			}
+			message = i18n.T("errors.npmMissing", name)
+		}
+		var commandErr *manager.CommandError
+		if errors.As(err, &commandErr) && commandErr.Code != "" {
+			message += " (" + commandErr.Code + ")"
+		}
+		return message
+	}
+	return err.Error()
+}
+
+// hint returns how to fix a failed operation, or "" if the failure was not
+// recognized or needs no fix
+func hint(err error) string {
+	for _, kind := range failureKinds {
+		if errors.Is(err, kind.err) {
+			if kind.err == context.Canceled {
+				return ""
+			}
+			// pipx, cargo and go are not package managers atm can choose
+			if command := missingCommand(err); command != "" && !slices.Contains(manager.BackendNames, command) {
+				return i18n.T("errors.hint", i18n.T("errors.hint.commandMissing", command))
+			}
+			return i18n.T("errors.hint", i18n.T("errors.hint."+kind.key))
+		}
+	}
+	return ""
+}
+
+// missingCommand returns the name of the program a failed operation could
+// not start, or "" if it started
+func missingCommand(err error) string {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return execErr.Name
+	}
+	return ""
+}
+
+// printHint prints how to fix a failed operation, if the failure was recognized
+func printHint(w io.Writer, err error) {
+	if h := hint(err); h != "" {
+		fmt.Fprintln(w, color.New(color.FgHiBlack).Sprint("  "+h))
+	}
+}
+
+// reportFailure prints a failed operation on a tool and how to fix it, or
+// the state an interrupted operation left the tool in. key is the i18n key of
+// the message, which takes the tool name and the error.
+func (a *App) reportFailure(w io.Writer, key string, tool config.Tool, err error) {
+	if errors.Is(err, context.Canceled) {
+		a.reportInterrupted(w, tool)
+		return
+	}
+	fmt.Fprintln(w, color.RedString("✗ "+i18n.T(key, tool.Name, a.describeError(err))))
+	printHint(w, err)
+}
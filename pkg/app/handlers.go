@@ -1,14 +1,21 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/cache"
 	"github.com/xiaoxu123195/atm/pkg/config"
 	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+	"github.com/xiaoxu123195/atm/pkg/ui"
 )
 
 // handleInstall handles the install action
@@ -24,47 +31,9 @@ func (a *App) handleInstall() {
 		items[i] = fmt.Sprintf("%s (%s)", tool.Name, tool.Package)
 	}
 
-	// Custom templates for checkbox
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "▸ {{ . | cyan }}",
-		Inactive: "  {{ . }}",
-		Selected: "▸ {{ . | cyan }}",
-	}
-
-	// Use a simple select for now (promptui doesn't have built-in multi-select)
-	// We'll use a workaround: show menu multiple times or use a different approach
-	prompt := promptui.Select{
-		Label:     i18n.T("install.selectToInstall") + " " + i18n.T("prompts.useArrowKeys"),
-		Items:     append(items, i18n.T("menu.exit")),
-		Templates: templates,
-		Size:      10,
-	}
-
-	var selectedTools []config.Tool
-
-	for {
-		index, _, err := prompt.Run()
-		if err != nil {
-			return
-		}
-
-		if index == len(items) { // Exit option
-			break
-		}
-
-		selectedTools = append(selectedTools, a.uninstalledTools[index])
-
-		// Ask if user wants to select more
-		confirmPrompt := promptui.Select{
-			Label: "Select more tools?",
-			Items: []string{"Yes", "No, start installation"},
-		}
-
-		choice, _, _ := confirmPrompt.Run()
-		if choice == 1 {
-			break
-		}
+	selectedTools, err := a.selectTools(i18n.T("install.selectToInstall"), a.uninstalledTools, items)
+	if err != nil {
+		return
 	}
 
 	if len(selectedTools) == 0 {
@@ -74,29 +43,18 @@ func (a *App) handleInstall() {
 
 	// Install selected tools
 	for _, tool := range selectedTools {
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = " " + i18n.T("install.installing", tool.Name)
-		s.Start()
+		if a.interrupted() {
+			return
+		}
+		s := a.startSpinner(i18n.T("install.installing", tool.Name))
 
-		err := a.packageManager.InstallPackage(tool.Package)
+		err := a.installTool(a.ctx, tool)
 		s.Stop()
 
 		if err != nil {
-			fmt.Println(color.RedString("✗ " + i18n.T("install.failed", tool.Name, err.Error())))
+			a.reportFailure(os.Stdout, "install.failed", tool, err)
 		} else {
-			// Cache version info
-			current, _ := a.packageManager.GetPackageVersion(tool.Package)
-			latest, _ := a.packageManager.GetLatestVersion(tool.Package)
-			a.versionCache[tool.Package] = &VersionInfo{
-				CurrentVersion: current,
-				LatestVersion:  latest,
-			}
-
 			fmt.Println(color.GreenString("✓ " + i18n.T("install.success", tool.Name)))
-
-			// Update cache lists
-			a.installedTools = append(a.installedTools, tool)
-			a.removeFromUninstalled(tool.Package)
 		}
 	}
 }
@@ -113,17 +71,14 @@ func (a *App) handleQuery() {
 	s.Start()
 
 	// Fetch version info concurrently
-	a.fetchVersionsConcurrently(a.installedTools)
+	a.fetchVersionsConcurrently(a.ctx, a.installedTools)
 
 	s.Stop()
 
 	fmt.Println(color.CyanString("\n" + i18n.T("query.installedTools") + "\n"))
 
 	for _, tool := range a.installedTools {
-		versionInfo := a.versionCache[tool.Package]
-		if versionInfo == nil {
-			versionInfo = &VersionInfo{"", ""}
-		}
+		versionInfo, _ := a.versionCache.Get(tool.Package)
 
 		versionText := versionInfo.CurrentVersion
 		if versionText == "" {
@@ -133,8 +88,9 @@ func (a *App) handleQuery() {
 		}
 
 		updateText := ""
-		if versionInfo.CurrentVersion != "" && versionInfo.LatestVersion != "" &&
-			versionInfo.CurrentVersion != versionInfo.LatestVersion {
+		if tool.UpdatePolicy() == config.PolicyPinned {
+			updateText = color.New(color.FgHiBlack).Sprint(" " + i18n.T("query.pinned"))
+		} else if hasUpdate(versionInfo) {
 			updateText = color.YellowString(" " + i18n.T("query.updateAvailable", versionInfo.LatestVersion))
 		} else if versionInfo.CurrentVersion != "" {
 			updateText = color.GreenString(" " + i18n.T("query.upToDate"))
@@ -165,16 +121,12 @@ func (a *App) handleUpdate() {
 	s.Start()
 
 	// Fetch version info concurrently
-	a.fetchVersionsConcurrently(a.installedTools)
+	a.fetchVersionsConcurrently(a.ctx, a.installedTools)
 
 	// Find updatable tools
 	var updatableTools []config.Tool
 	for _, tool := range a.installedTools {
-		versionInfo := a.versionCache[tool.Package]
-		if versionInfo != nil &&
-			versionInfo.CurrentVersion != "" &&
-			versionInfo.LatestVersion != "" &&
-			versionInfo.CurrentVersion != versionInfo.LatestVersion {
+		if versionInfo, _ := a.versionCache.Get(tool.Package); updateOffered(tool, versionInfo) {
 			updatableTools = append(updatableTools, tool)
 		}
 	}
@@ -189,50 +141,16 @@ func (a *App) handleUpdate() {
 	// Create choices with version info
 	items := make([]string, len(updatableTools))
 	for i, tool := range updatableTools {
-		versionInfo := a.versionCache[tool.Package]
+		versionInfo, _ := a.versionCache.Get(tool.Package)
 		items[i] = fmt.Sprintf("%s (v%s → v%s)",
 			tool.Name,
 			versionInfo.CurrentVersion,
 			versionInfo.LatestVersion)
 	}
 
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "▸ {{ . | cyan }}",
-		Inactive: "  {{ . }}",
-		Selected: "▸ {{ . | cyan }}",
-	}
-
-	prompt := promptui.Select{
-		Label:     i18n.T("update.selectToUpdate") + " " + i18n.T("prompts.useArrowKeys"),
-		Items:     append(items, i18n.T("menu.exit")),
-		Templates: templates,
-		Size:      10,
-	}
-
-	var selectedTools []config.Tool
-
-	for {
-		index, _, err := prompt.Run()
-		if err != nil {
-			return
-		}
-
-		if index == len(items) { // Exit option
-			break
-		}
-
-		selectedTools = append(selectedTools, updatableTools[index])
-
-		confirmPrompt := promptui.Select{
-			Label: "Select more tools?",
-			Items: []string{"Yes", "No, start update"},
-		}
-
-		choice, _, _ := confirmPrompt.Run()
-		if choice == 1 {
-			break
-		}
+	selectedTools, err := a.selectTools(i18n.T("update.selectToUpdate"), updatableTools, items)
+	if err != nil {
+		return
 	}
 
 	if len(selectedTools) == 0 {
@@ -240,24 +158,24 @@ func (a *App) handleUpdate() {
 		return
 	}
 
+	if !a.confirmUpdate(selectedTools) {
+		return
+	}
+
 	// Update selected tools
 	for _, tool := range selectedTools {
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = " " + i18n.T("update.updating", tool.Name)
-		s.Start()
+		if a.interrupted() {
+			return
+		}
+		s := a.startSpinner(i18n.T("update.updating", tool.Name))
 
-		err := a.packageManager.UpdatePackage(tool.Package)
+		err := a.updateTool(a.ctx, tool)
 		s.Stop()
 
 		if err != nil {
-			fmt.Println(color.RedString("✗ " + i18n.T("update.failed", tool.Name, err.Error())))
+			a.reportFailure(os.Stdout, "update.failed", tool, err)
 		} else {
 			fmt.Println(color.GreenString("✓ " + i18n.T("update.success", tool.Name)))
-
-			// Update cache with new version
-			if versionInfo := a.versionCache[tool.Package]; versionInfo != nil {
-				versionInfo.CurrentVersion = versionInfo.LatestVersion
-			}
 		}
 	}
 }
@@ -275,43 +193,9 @@ func (a *App) handleUninstall() {
 		items[i] = fmt.Sprintf("%s (%s)", tool.Name, tool.Package)
 	}
 
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}",
-		Active:   "▸ {{ . | cyan }}",
-		Inactive: "  {{ . }}",
-		Selected: "▸ {{ . | cyan }}",
-	}
-
-	prompt := promptui.Select{
-		Label:     i18n.T("uninstall.selectToUninstall") + " " + i18n.T("prompts.useArrowKeys"),
-		Items:     append(items, i18n.T("menu.exit")),
-		Templates: templates,
-		Size:      10,
-	}
-
-	var selectedTools []config.Tool
-
-	for {
-		index, _, err := prompt.Run()
-		if err != nil {
-			return
-		}
-
-		if index == len(items) { // Exit option
-			break
-		}
-
-		selectedTools = append(selectedTools, a.installedTools[index])
-
-		confirmPrompt := promptui.Select{
-			Label: "Select more tools?",
-			Items: []string{"Yes", "No, proceed to confirm"},
-		}
-
-		choice, _, _ := confirmPrompt.Run()
-		if choice == 1 {
-			break
-		}
+	selectedTools, err := a.selectTools(i18n.T("uninstall.selectToUninstall"), a.installedTools, items)
+	if err != nil {
+		return
 	}
 
 	if len(selectedTools) == 0 {
@@ -333,24 +217,259 @@ func (a *App) handleUninstall() {
 
 	// Uninstall selected tools
 	for _, tool := range selectedTools {
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = " " + i18n.T("uninstall.uninstalling", tool.Name)
-		s.Start()
+		if a.interrupted() {
+			return
+		}
+		s := a.startSpinner(i18n.T("uninstall.uninstalling", tool.Name))
 
-		err := a.packageManager.UninstallPackage(tool.Package)
+		err := a.uninstallTool(a.ctx, tool)
 		s.Stop()
 
 		if err != nil {
-			fmt.Println(color.RedString("✗ " + i18n.T("uninstall.failed", tool.Name, err.Error())))
+			a.reportFailure(os.Stdout, "uninstall.failed", tool, err)
 		} else {
 			fmt.Println(color.GreenString("✓ " + i18n.T("uninstall.success", tool.Name)))
+		}
+	}
+}
+
+// selectTools lets the user check any number of tools in a list, where
+// items[i] describes tools[i]. Each tool is listed and returned only once.
+func (a *App) selectTools(label string, tools []config.Tool, items []string) ([]config.Tool, error) {
+	var unique []config.Tool
+	var uniqueItems []string
+	seen := make(map[string]bool)
+	for i, tool := range tools {
+		if seen[tool.Package] {
+			continue
+		}
+		seen[tool.Package] = true
+		unique = append(unique, tool)
+		uniqueItems = append(uniqueItems, items[i])
+	}
+
+	prompt := ui.MultiSelect{
+		Label:      label,
+		Items:      uniqueItems,
+		Help:       i18n.T("prompts.pressSpace"),
+		FilterHelp: i18n.T("prompts.filter"),
+		NoMatches:  i18n.T("prompts.noMatches"),
+		Size:       10,
+	}
+	indexes, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]config.Tool, len(indexes))
+	for n, i := range indexes {
+		selected[n] = unique[i]
+	}
+	return selected, nil
+}
+
+// installTool installs the version of a tool its catalog constraint selects
+// and moves it to the installed list
+func (a *App) installTool(ctx context.Context, tool config.Tool) (err error) {
+	version := ""
+	defer func() {
+		if err == nil {
+			version = a.currentVersion(tool)
+		}
+		a.logOperation(audit.ActionInstall, tool, "", version, err)
+	}()
+
+	if tool.Version != "" {
+		if version, err = a.resolveVersion(ctx, tool, tool.Version); err != nil {
+			return err
+		}
+	}
+	return a.installToolVersion(ctx, tool, version)
+}
+
+// installToolVersion installs an exact version of a tool, upgrading or
+// downgrading it if it is already installed, and checks that the version
+// was installed. An empty version installs the latest.
+func (a *App) installToolVersion(ctx context.Context, tool config.Tool, version string) error {
+	if err := a.installPackage(ctx, tool, version); err != nil {
+		return err
+	}
+
+	a.refreshVersionInfo(ctx, tool)
+
+	// Update cache lists
+	if !a.isInstalled(tool) {
+		a.installedTools = append(a.installedTools, tool)
+		a.removeFromUninstalled(tool.Package)
+	}
+
+	// The package manager can exit cleanly without installing the requested version
+	if version != "" {
+		if versionInfo, _ := a.versionCache.Get(tool.Package); versionInfo.CurrentVersion != version {
+			return fmt.Errorf("%s", i18n.T("install.versionMismatch", versionInfo.CurrentVersion))
+		}
+	}
+	return nil
+}
 
-			// Update cache lists
-			a.uninstalledTools = append(a.uninstalledTools, tool)
-			a.removeFromInstalled(tool.Package)
-			delete(a.versionCache, tool.Package)
+// updateTool updates an installed tool to its latest version, or to the
+// newest version its catalog constraint allows
+func (a *App) updateTool(ctx context.Context, tool config.Tool) (err error) {
+	previous := a.currentVersion(tool)
+	target := ""
+	defer func() {
+		if err == nil {
+			target = a.currentVersion(tool)
+		}
+		a.logOperation(audit.ActionUpdate, tool, previous, target, err)
+	}()
+
+	if tool.Version != "" {
+		if target, err = a.resolveVersion(ctx, tool, tool.Version); err != nil {
+			return err
+		}
+		if err := a.installToolVersion(ctx, tool, target); err != nil {
+			return err
+		}
+	} else {
+		if versionInfo, _ := a.versionCache.Get(tool.Package); versionInfo.Constraint == "" {
+			target = versionInfo.LatestVersion
 		}
+		if err := a.updatePackage(ctx, tool); err != nil {
+			return err
+		}
+		a.refreshVersionInfo(ctx, tool)
+	}
+
+	a.recordChange(tool, previous, a.currentVersion(tool))
+	return nil
+}
+
+// uninstallTool uninstalls a tool and moves it to the uninstalled list
+func (a *App) uninstallTool(ctx context.Context, tool config.Tool) error {
+	previous := a.currentVersion(tool)
+	err := a.uninstallPackage(ctx, tool)
+	a.logOperation(audit.ActionUninstall, tool, previous, "", err)
+	if err != nil {
+		return err
 	}
+
+	// Update cache lists
+	a.uninstalledTools = append(a.uninstalledTools, tool)
+	a.removeFromInstalled(tool.Package)
+	a.versionCache.Invalidate(tool.Package)
+	return nil
+}
+
+// resolveVersion returns the exact version a constraint selects. Sources
+// other than npm only accept exact versions, which select themselves, and
+// "latest".
+func (a *App) resolveVersion(ctx context.Context, tool config.Tool, constraint string) (string, error) {
+	if !tool.IsNpm() {
+		if constraint == "latest" {
+			return a.packageManager.GetLatestVersionFrom(ctx, tool.Source, tool.Package)
+		}
+		return strings.TrimPrefix(constraint, "v"), nil
+	}
+	return a.packageManager.ResolveVersion(ctx, tool.Package, constraint)
+}
+
+// installPackage installs a tool's package at an exact version, or the
+// latest if version is empty, from the tool's source
+func (a *App) installPackage(ctx context.Context, tool config.Tool, version string) error {
+	if !tool.IsNpm() {
+		return a.packageManager.InstallFrom(ctx, tool.Source, tool.Package, version)
+	}
+
+	spec := tool.Package
+	if version != "" {
+		spec += "@" + version
+	}
+	err := a.packageManager.InstallPackage(ctx, spec)
+	if err != nil && a.offerUserPrefix(err) {
+		err = a.packageManager.InstallPackage(ctx, spec)
+	}
+	return err
+}
+
+// updatePackage updates a tool's package to its latest version
+func (a *App) updatePackage(ctx context.Context, tool config.Tool) error {
+	if !tool.IsNpm() {
+		return a.packageManager.UpdateFrom(ctx, tool.Source, tool.Package)
+	}
+
+	err := a.packageManager.UpdatePackage(ctx, tool.Package)
+	if err != nil && a.offerUserPrefix(err) {
+		// The new prefix does not have the tool yet, so install the latest version into it
+		err = a.packageManager.InstallPackage(ctx, tool.Package)
+	}
+	return err
+}
+
+// uninstallPackage removes a tool's package with the tool's source
+func (a *App) uninstallPackage(ctx context.Context, tool config.Tool) error {
+	if !tool.IsNpm() {
+		return a.packageManager.UninstallFrom(ctx, tool.Source, tool.Package)
+	}
+	return a.packageManager.UninstallPackage(ctx, tool.Package)
+}
+
+// packageVersion returns the installed version of a tool's package
+func (a *App) packageVersion(ctx context.Context, tool config.Tool) (string, error) {
+	if !tool.IsNpm() {
+		return a.packageManager.GetVersionFrom(ctx, tool.Source, tool.Package)
+	}
+	return a.packageManager.GetPackageVersion(ctx, tool.Package)
+}
+
+// backendName returns the program that manages a tool's package: the
+// package manager for npm packages, or the name of the tool's source
+func (a *App) backendName(tool config.Tool) string {
+	if !tool.IsNpm() {
+		return tool.Source
+	}
+	return a.packageManager.Backend().Name()
+}
+
+// refreshVersionInfo invalidates the cached versions of a tool after it was
+// installed or updated and records the version now installed
+func (a *App) refreshVersionInfo(ctx context.Context, tool config.Tool) {
+	a.versionCache.Invalidate(tool.Package)
+	if current, err := a.packageVersion(ctx, tool); err == nil {
+		a.versionCache.SetCurrent(tool.Package, current)
+	}
+}
+
+// isInstalled reports whether a tool is in the installed list
+func (a *App) isInstalled(tool config.Tool) bool {
+	for _, t := range a.installedTools {
+		if t.Package == tool.Package {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUpdate reports whether the cached version info shows a release newer
+// than the installed one. Versions that are not valid SemVer are compared as text.
+func hasUpdate(versionInfo cache.VersionInfo) bool {
+	current, latest := versionInfo.CurrentVersion, versionInfo.LatestVersion
+	if current == "" || latest == "" {
+		return false
+	}
+
+	currentVersion, err1 := semver.Parse(current)
+	latestVersion, err2 := semver.Parse(latest)
+	if err1 != nil || err2 != nil {
+		return current != latest
+	}
+	return semver.Compare(latestVersion, currentVersion) > 0
+}
+
+// updateOffered reports whether an update should be offered for a tool:
+// one is available and the tool is not pinned
+func updateOffered(tool config.Tool, versionInfo cache.VersionInfo) bool {
+	return tool.UpdatePolicy() != config.PolicyPinned && hasUpdate(versionInfo)
 }
 
 // removeFromInstalled removes a tool from the installed list
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+)
+
+// dateLayout is the date format accepted by --since and --until
+const dateLayout = "2006-01-02"
+
+// cmdHistory prints the operations recorded in the audit log
+func (a *App) cmdHistory(args []string) int {
+	fs := newFlagSet("history")
+	toolName := fs.String("tool", "", "only show operations on this tool")
+	since := fs.String("since", "", "only show operations on or after this date (YYYY-MM-DD or RFC 3339)")
+	until := fs.String("until", "", "only show operations before the end of this date (YYYY-MM-DD or RFC 3339)")
+	output := outputFlag(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+	if !validOutputFormat(*output) {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.invalidOutput", *output)))
+		return ExitUsage
+	}
+
+	var filter audit.Filter
+	var err error
+	if *since != "" {
+		if filter.Since, err = parseDate(*since, false); err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("history.invalidDate", *since)))
+			return ExitUsage
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = parseDate(*until, true); err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("history.invalidDate", *until)))
+			return ExitUsage
+		}
+	}
+
+	if *toolName != "" {
+		// Operations on tools that were removed from the catalog can still be
+		// found by package name
+		filter.Package = *toolName
+		if cfg, err := config.Load(); err == nil {
+			if tool, found := cfg.FindTool(*toolName); found {
+				filter.Package = tool.Package
+			}
+		}
+	}
+
+	path, err := auditLogPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+		return ExitFailure
+	}
+
+	records, err := audit.NewLog(path).Read(filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("history.readError", err.Error())))
+		return ExitFailure
+	}
+
+	if *output != OutputText {
+		if records == nil {
+			records = []audit.Record{}
+		}
+		if err := writeDocument(os.Stdout, *output, HistoryReport{
+			SchemaVersion: HistorySchemaVersion,
+			Records:       records,
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	if len(records) == 0 {
+		fmt.Println(color.YellowString(i18n.T("history.empty")))
+		return ExitOK
+	}
+
+	for _, r := range records {
+		result := color.GreenString("✓")
+		if !r.Success {
+			result = color.RedString("✗ " + r.Error)
+		}
+
+		fmt.Printf("%s  %-10s %-10s %-20s %-24s %s  %s\n",
+			color.New(color.FgHiBlack).Sprint(r.Time.Local().Format("2006-01-02 15:04:05")),
+			r.User,
+			r.Action,
+			r.Tool,
+			formatTransition(r.FromVersion, r.ToVersion),
+			color.New(color.FgHiBlack).Sprint(r.Backend),
+			result)
+	}
+
+	return ExitOK
+}
+
+// logOperation appends an operation to the audit log. A failure to write the
+// log is reported but does not fail the operation.
+func (a *App) logOperation(action string, tool config.Tool, from, to string, opErr error) {
+	if a.auditLog == nil {
+		return
+	}
+
+	record := audit.Record{
+		Action:      action,
+		Tool:        tool.Name,
+		Package:     tool.Package,
+		FromVersion: from,
+		ToVersion:   to,
+		Backend:     a.backendName(tool),
+		Success:     opErr == nil,
+	}
+	if opErr != nil {
+		record.Error = opErr.Error()
+	}
+
+	if err := a.auditLog.Append(record); err != nil {
+		fmt.Fprintln(os.Stderr, color.YellowString(i18n.T("history.writeError", err.Error())))
+	}
+}
+
+// auditLogPath returns the location of the audit log
+func auditLogPath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, audit.FileName), nil
+}
+
+// parseDate parses a --since or --until value. A bare date is local
+// midnight, or with endOfDay the following midnight so the day is included.
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(dateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// formatTransition formats the versions before and after an operation
+func formatTransition(from, to string) string {
+	switch {
+	case from == "" && to == "":
+		return ""
+	case from == "":
+		return "→ v" + to
+	case to == "":
+		return "v" + from + " →"
+	}
+	return "v" + from + " → v" + to
+}
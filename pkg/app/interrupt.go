@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+)
+
+// ErrInterrupted is returned by Run when the user interrupted an operation
+var ErrInterrupted = errors.New("interrupted")
+
+// stateTimeout bounds reading the installed version after an interruption
+const stateTimeout = 10 * time.Second
+
+// handleInterrupt makes SIGINT cancel a.ctx, which interrupts the running
+// package manager and registry requests. After the first SIGINT the default
+// handling is restored, so pressing Ctrl+C again exits at once. The returned
+// function stops the handling.
+func (a *App) handleInterrupt() func() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	a.ctx = ctx
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return stop
+}
+
+// interrupted reports whether the user interrupted atm
+func (a *App) interrupted() bool {
+	return errors.Is(a.ctx.Err(), context.Canceled)
+}
+
+// reportInterrupted tells the user that an operation on a tool was
+// interrupted and which version of the tool it left installed
+func (a *App) reportInterrupted(w io.Writer, tool config.Tool) {
+	// The cache still holds the version from before the operation
+	previous := a.currentVersion(tool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+	current, _ := a.packageVersion(ctx, tool)
+
+	var state string
+	switch {
+	case previous == "" && current == "":
+		state = i18n.T("interrupt.notInstalled", tool.Name)
+	case previous == current:
+		state = i18n.T("interrupt.unchanged", tool.Name, current)
+	case previous == "":
+		state = i18n.T("interrupt.installed", tool.Name, current)
+	case current == "":
+		state = i18n.T("interrupt.removed", tool.Name, previous)
+	default:
+		state = i18n.T("interrupt.changed", tool.Name, current, previous)
+	}
+
+	fmt.Fprintln(w, color.YellowString("⚠ "+i18n.T("interrupt.interrupted", state)))
+	fmt.Fprintln(w, color.New(color.FgHiBlack).Sprint("  "+i18n.T("interrupt.hint")))
+}
+
+// reportNothingChanged tells the user that atm was interrupted before it
+// changed anything and returns ExitInterrupted
+func reportNothingChanged() int {
+	fmt.Fprintln(os.Stderr, color.YellowString("⚠ "+i18n.T("interrupt.interrupted", i18n.T("interrupt.nothingChanged"))))
+	return ExitInterrupted
+}
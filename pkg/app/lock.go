@@ -0,0 +1,253 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/lock"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+)
+
+// cmdLock writes the installed versions of catalog tools to a lockfile
+func (a *App) cmdLock(args []string) int {
+	fs := newFlagSet("lock")
+	file := fs.String("file", lock.FileName, "lockfile path")
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	if len(a.installedTools) == 0 {
+		fmt.Println(color.YellowString(i18n.T("query.noneInstalled")))
+		return ExitOK
+	}
+
+	installed, err := a.installedVersions(a.ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+		return ExitFailure
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("lock.resolving")
+	s.Start()
+	packuments := a.fetchPackuments(a.installedTools)
+	s.Stop()
+
+	lockfile := lock.New()
+	for _, tool := range a.installedTools {
+		entry := lock.Entry{
+			Name:    tool.Name,
+			Package: tool.Package,
+			Source:  tool.Source,
+			Version: installed[tool.Package],
+		}
+
+		if packument := packuments[tool.Package]; packument != nil {
+			entry.Integrity = packument.Versions[entry.Version].Dist.Integrity
+		}
+		// Only the npm registry publishes integrity hashes
+		if entry.Integrity == "" && tool.IsNpm() {
+			fmt.Fprintln(os.Stderr, color.YellowString(i18n.T("lock.integrityUnavailable", tool.Name, entry.Version)))
+		}
+
+		lockfile.Tools = append(lockfile.Tools, entry)
+	}
+
+	if err := lockfile.Write(*file); err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+		return ExitFailure
+	}
+
+	fmt.Println(color.GreenString("✓ " + i18n.T("lock.written", *file, len(lockfile.Tools))))
+	return ExitOK
+}
+
+// cmdSync installs, upgrades or downgrades tools to match a lockfile.
+// With --check it only reports drift.
+func (a *App) cmdSync(args []string) int {
+	fs := newFlagSet("sync")
+	file := fs.String("file", lock.FileName, "lockfile path")
+	check := fs.Bool("check", false, "report drift without changing anything")
+	if _, err := parseFlags(fs, args); err != nil {
+		return flagExitCode(err)
+	}
+
+	lockfile, err := lock.Read(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("sync.readError", err.Error())))
+		return ExitFailure
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	installed, err := a.installedVersions(a.ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(err.Error()))
+		return ExitFailure
+	}
+
+	// Each entry is synced with the installer of its own source; entries
+	// whose source is unknown or differs from the catalog's are refused
+	code := ExitOK
+	tools := make(map[lock.Entry]config.Tool, len(lockfile.Tools))
+	for _, entry := range lockfile.Tools {
+		tool, err := a.lockedTool(entry)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("sync.failed", entry.Name, err.Error())))
+			code = ExitFailure
+			continue
+		}
+		tools[entry] = tool
+		if _, inCatalog := a.config.FindTool(entry.Package); !inCatalog && !tool.IsNpm() {
+			// installedVersions only reads catalog tools from other sources
+			version, _ := a.packageManager.GetVersionFrom(a.ctx, tool.Source, tool.Package)
+			installed[tool.Package] = version
+		}
+	}
+
+	var drift []lock.Drift
+	for _, d := range lockfile.Drift(installed) {
+		if _, ok := tools[d.Entry]; ok {
+			drift = append(drift, d)
+		}
+	}
+	if len(drift) == 0 {
+		if code == ExitOK {
+			fmt.Println(color.GreenString(i18n.T("sync.inSync", *file)))
+		}
+		return code
+	}
+
+	fmt.Println(color.YellowString(i18n.T("sync.drift", len(drift), *file)))
+	for _, d := range drift {
+		if d.InstalledVersion == "" {
+			fmt.Printf("  %s %s\n", color.YellowString("•"), i18n.T("sync.driftMissing", d.Name, d.Version))
+		} else {
+			fmt.Printf("  %s %s\n", color.YellowString("•"), i18n.T("sync.driftVersion", d.Name, d.InstalledVersion, d.Version))
+		}
+	}
+
+	if *check {
+		return ExitFailure
+	}
+	fmt.Println()
+
+	for _, d := range drift {
+		if a.interrupted() {
+			return ExitInterrupted
+		}
+		tool := tools[d.Entry]
+
+		s := a.startSpinner(i18n.T("sync.installing", tool.Name, d.Version))
+
+		var err error
+		// Only the npm registry publishes integrity hashes
+		if tool.IsNpm() {
+			err = a.verifyIntegrity(d.Entry)
+		}
+		if err == nil {
+			err = a.installToolVersion(a.ctx, tool, d.Version)
+		}
+		s.Stop()
+
+		a.logOperation(audit.ActionInstall, tool, d.InstalledVersion, d.Version, err)
+
+		if err != nil {
+			a.reportFailure(os.Stderr, "sync.failed", tool, err)
+			code = failureCode(code, err)
+		} else {
+			a.recordChange(tool, d.InstalledVersion, d.Version)
+			fmt.Println(color.GreenString("✓ " + i18n.T("sync.success", tool.Name, d.Version)))
+		}
+	}
+
+	return code
+}
+
+// lockedTool returns the tool a lockfile entry pins: the catalog tool of the
+// same package, which must come from the same source, or a tool installed
+// from the entry's source
+func (a *App) lockedTool(entry lock.Entry) (config.Tool, error) {
+	locked := config.Tool{Name: entry.Name, Package: entry.Package, Source: entry.Source}
+	if !slices.Contains(config.SourceNames, locked.SourceName()) {
+		return config.Tool{}, fmt.Errorf("%s", i18n.T("sync.unknownSource", entry.Source))
+	}
+
+	tool, found := a.config.FindTool(entry.Package)
+	if !found || tool.Package != entry.Package {
+		return locked, nil
+	}
+	if tool.SourceName() != locked.SourceName() {
+		return config.Tool{}, fmt.Errorf("%s", i18n.T("sync.sourceMismatch", locked.SourceName(), tool.SourceName()))
+	}
+	return tool, nil
+}
+
+// verifyIntegrity checks that the registry still serves the locked version
+// with the integrity hash recorded in the lockfile
+func (a *App) verifyIntegrity(entry lock.Entry) error {
+	if entry.Integrity == "" {
+		return nil
+	}
+
+	packument, err := a.packageManager.GetPackument(a.ctx, entry.Package)
+	if err != nil {
+		return err
+	}
+
+	version, ok := packument.Versions[entry.Version]
+	if !ok {
+		return fmt.Errorf("%s", i18n.T("sync.versionNotFound", entry.Version))
+	}
+	if version.Dist.Integrity != entry.Integrity {
+		return fmt.Errorf("%s", i18n.T("sync.integrityMismatch", entry.Version))
+	}
+	return nil
+}
+
+// fetchPackuments fetches the registry metadata of several tools concurrently.
+// Tools from other sources and tools whose packument could not be fetched are
+// missing from the result.
+func (a *App) fetchPackuments(tools []config.Tool) map[string]*manager.Packument {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	packuments := make(map[string]*manager.Packument)
+
+	for _, tool := range tools {
+		if !tool.IsNpm() {
+			continue
+		}
+		wg.Add(1)
+		go func(t config.Tool) {
+			defer wg.Done()
+			a.semaphore <- struct{}{}        // Acquire
+			defer func() { <-a.semaphore }() // Release
+
+			packument, err := a.packageManager.GetPackument(a.ctx, t.Package)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			packuments[t.Package] = packument
+			mu.Unlock()
+		}(tool)
+	}
+
+	wg.Wait()
+	return packuments
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/changelog"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+)
+
+// maxReleasePages bounds the pages of releases read for the notes of a tool
+const maxReleasePages = 3
+
+// maxNoteReleases is the number of releases whose notes are shown per tool
+const maxNoteReleases = 10
+
+// maxNoteLines is the number of lines shown of the notes of a release
+const maxNoteLines = 30
+
+// changelogFiles are the names CHANGELOG files go by, tried in order
+var changelogFiles = []string{"CHANGELOG.md", "CHANGES.md", "HISTORY.md"}
+
+// htmlComment matches the HTML comments release templates leave in notes
+var htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// noteHeading matches a Markdown heading in release notes
+var noteHeading = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+
+// markdownBullet matches a Markdown list item, keeping its indentation
+var markdownBullet = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+
+// releaseNotes holds the notes between the installed and the latest version
+// of a tool, and where to read more
+type releaseNotes struct {
+	entries []changelog.Entry
+	link    string
+	err     error
+}
+
+// confirmUpdate asks whether to update the selected tools, offering to show
+// their release notes first
+func (a *App) confirmUpdate(tools []config.Tool) bool {
+	shown := false
+	for {
+		items := []string{i18n.T("update.updateNow"), i18n.T("update.showNotes"), i18n.T("update.cancel")}
+		if shown {
+			items = []string{items[0], items[2]}
+		}
+		prompt := promptui.Select{
+			Label: i18n.T("update.confirm", len(tools)) + " " + i18n.T("prompts.useArrowKeys"),
+			Items: items,
+		}
+
+		index, _, err := prompt.Run()
+		if err != nil || index == len(items)-1 {
+			fmt.Println(color.YellowString(i18n.T("update.cancelled")))
+			return false
+		}
+		if index == 0 {
+			return true
+		}
+
+		a.showReleaseNotes(tools)
+		shown = true
+	}
+}
+
+// showReleaseNotes prints the release notes of every version between the
+// installed and the latest version of each tool
+func (a *App) showReleaseNotes(tools []config.Tool) {
+	s := a.startSpinner(i18n.T("update.fetchingNotes"))
+	notes := make([]releaseNotes, len(tools))
+	for i, tool := range tools {
+		if a.interrupted() {
+			break
+		}
+		versionInfo, _ := a.versionCache.Get(tool.Package)
+		notes[i] = a.fetchReleaseNotes(tool, versionInfo.CurrentVersion, versionInfo.LatestVersion)
+	}
+	s.Stop()
+
+	for i, tool := range tools {
+		versionInfo, _ := a.versionCache.Get(tool.Package)
+		fmt.Println()
+		fmt.Println(color.New(color.Bold, color.FgCyan).Sprint(i18n.T("update.notesHeader", tool.Name, versionInfo.CurrentVersion, versionInfo.LatestVersion)))
+		printReleaseNotes(notes[i])
+	}
+	fmt.Println()
+}
+
+// fetchReleaseNotes gathers the notes of the versions of a tool newer than
+// from, up to and including to: from the releases of its GitHub repository,
+// or, if those publish no notes, from the first of its CHANGELOG files with
+// sections for those versions
+func (a *App) fetchReleaseNotes(tool config.Tool, from, to string) releaseNotes {
+	repository, err := a.packageManager.Repository(a.ctx, tool.SourceName(), tool.Package, to)
+	if err != nil {
+		return releaseNotes{err: err}
+	}
+	repo, ok := repository.GitHub()
+	if !ok {
+		return releaseNotes{link: repository.URL}
+	}
+
+	notes := releaseNotes{link: "https://github.com/" + repo + "/releases"}
+	var releases []github.Release
+	for page := 1; page <= maxReleasePages; page++ {
+		list, err := a.github.Releases(a.ctx, repo, page)
+		if err != nil {
+			notes.err = err
+			return notes
+		}
+		releases = append(releases, list...)
+		if len(list) < github.ReleasesPerPage || changelog.Reaches(list, tool.Package, from) {
+			break
+		}
+	}
+	notes.entries = changelog.FromReleases(releases, tool.Package, from, to)
+	if hasNotes(notes.entries) {
+		return notes
+	}
+
+	// Monorepos keep a CHANGELOG in the directory of each package
+	var files []string
+	for _, name := range changelogFiles {
+		if repository.Directory != "" {
+			files = append(files, path.Join(repository.Directory, name))
+		}
+		files = append(files, name)
+	}
+	for _, file := range files {
+		data, err := a.github.File(a.ctx, repo, file)
+		if github.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			notes.err = err
+			return notes
+		}
+		// A file without sections for these versions may be stale, e.g. a
+		// root CHANGELOG that points to the ones of the packages
+		if entries := changelog.Parse(string(data), from, to); len(entries) > 0 {
+			notes.entries = entries
+			notes.link = "https://github.com/" + repo + "/blob/HEAD/" + file
+			break
+		}
+	}
+	return notes
+}
+
+// hasNotes reports whether any entry has notes
+func hasNotes(entries []changelog.Entry) bool {
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.Body) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// printReleaseNotes prints the notes of a tool, newest release first,
+// shortening long notes
+func printReleaseNotes(notes releaseNotes) {
+	faint := color.New(color.FgHiBlack)
+	if notes.err != nil {
+		message := notes.err.Error()
+		if errors.Is(notes.err, github.ErrRateLimited) {
+			message = i18n.T("update.notesRateLimited")
+		}
+		fmt.Println(color.YellowString("  " + i18n.T("update.notesFailed", message)))
+	} else if len(notes.entries) == 0 {
+		fmt.Println(faint.Sprint("  " + i18n.T("update.noNotes")))
+	}
+
+	for i, entry := range notes.entries {
+		if i == maxNoteReleases {
+			fmt.Println(faint.Sprint("  " + i18n.T("update.moreReleases", len(notes.entries)-i)))
+			break
+		}
+
+		title := color.New(color.Bold).Sprint("v" + entry.Version)
+		if t := printable(entry.Title); t != "" {
+			title += " " + t
+		}
+		if !entry.Date.IsZero() {
+			title += faint.Sprint(" · " + entry.Date.Local().Format("2006-01-02"))
+		}
+		fmt.Println("  " + title)
+
+		lines := noteLines(entry.Body)
+		if len(lines) == 0 {
+			fmt.Println(faint.Sprint("    " + i18n.T("update.emptyNotes")))
+		}
+		for j, line := range lines {
+			if j == maxNoteLines {
+				fmt.Println(faint.Sprint("    " + i18n.T("update.moreLines", len(lines)-j)))
+				break
+			}
+			fmt.Println("    " + line)
+		}
+	}
+
+	if notes.link != "" {
+		fmt.Println(faint.Sprint("  " + i18n.T("update.notesAt", notes.link)))
+	}
+}
+
+// noteLines prepares Markdown notes for the terminal: comments are removed,
+// list items get bullets and runs of blank lines are collapsed
+func noteLines(body string) []string {
+	body = htmlComment.ReplaceAllString(strings.ReplaceAll(body, "\r\n", "\n"), "")
+
+	var lines []string
+	blank := true
+	for _, line := range strings.Split(body, "\n") {
+		// Drop control characters, which could move the cursor or clear the
+		// screen, but keep the indentation of nested lists
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		line = printable(line)
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		line = indent + line
+
+		if match := noteHeading.FindStringSubmatch(line); match != nil {
+			line = color.New(color.Bold).Sprint(match[1])
+		} else {
+			line = markdownBullet.ReplaceAllString(line, "$1• ")
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// TestReleaseNotesSkipStaleChangelog reads the notes from the first CHANGELOG
+// file with sections for the versions, past one that has none
+func TestReleaseNotesSkipStaleChangelog(t *testing.T) {
+	files := map[string]string{
+		"/repos/acme/tool/contents/CHANGELOG.md": "# Changelog\n\nSee CHANGES.md.\n\n## 0.1.0\n\n- First release\n",
+		"/repos/acme/tool/contents/CHANGES.md":   "# Changes\n\n## 1.2.0\n\n- Add --json\n\n## 1.1.0\n\n- Faster startup\n\n## 1.0.0\n\n- Stable\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/tool/releases" {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, data)
+	}))
+	defer server.Close()
+	a := newTestApp(t, server, 1)
+	a.github = &github.Client{APIURL: server.URL + "/", HTTPClient: server.Client()}
+
+	tool := config.Tool{Name: "Tool", Package: "acme/tool", Source: config.SourceGitHubRelease}
+	notes := a.fetchReleaseNotes(tool, "1.0.0", "1.2.0")
+	if notes.err != nil {
+		t.Fatal(notes.err)
+	}
+	if len(notes.entries) != 2 || notes.entries[0].Version != "1.2.0" || notes.entries[1].Version != "1.1.0" {
+		t.Errorf("entries = %+v, want 1.2.0 and 1.1.0 from CHANGES.md", notes.entries)
+	}
+	if want := "https://github.com/acme/tool/blob/HEAD/CHANGES.md"; notes.link != want {
+		t.Errorf("link = %q, want %q", notes.link, want)
+	}
+}
+
+func TestNoteLines(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"bullets and headings", "## Features\r\n\r\n- Plugins\r\n  * Nested\r\n", []string{"Features", "", "• Plugins", "  • Nested"}},
+		{"blank lines collapsed", "\n\nA\n\n\n\nB\n\n", []string{"A", "", "B"}},
+		{"comments removed", "<!-- Release checklist\n- [ ] tag -->\nShipped", []string{"Shipped"}},
+		{"escape sequences removed", "\x1b[2J\x1b[H- Fix\x1b[31m crash\x1b[0m\n\x1b]0;pwned\x07Title\n\x1b[1A\x1b[K", []string{"• Fix crash", "Title"}},
+		{"control characters removed", "Bell\a and\b back\x00space", []string{"Bell and backspace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noteLines(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("noteLines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"added 1 package in 2s", "added 1 package in 2s"},
+		{"\x1b[32m✓\x1b[0m done", "✓ done"},
+		{"\x1b[?25lhidden cursor\x1b[?25h", "hidden cursor"},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"progress\r  ", "progress"},
+		{"lone \x1b escape", "lone  escape"},
+	}
+	for _, tt := range tests {
+		if got := printable(tt.line); got != tt.want {
+			t.Errorf("printable(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
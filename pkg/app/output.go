@@ -0,0 +1,114 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// StatusSchemaVersion identifies the layout of StatusReport.
+// It is incremented whenever a field is removed or changes meaning.
+const StatusSchemaVersion = 1
+
+// ToolStatus is the machine-readable status of a catalog tool
+type ToolStatus struct {
+	Name            string `json:"name" yaml:"name"`
+	Package         string `json:"package" yaml:"package"`
+	Source          string `json:"source" yaml:"source"`
+	Installed       bool   `json:"installed" yaml:"installed"`
+	CurrentVersion  string `json:"currentVersion" yaml:"currentVersion"`
+	LatestVersion   string `json:"latestVersion" yaml:"latestVersion"`
+	UpdateAvailable bool   `json:"updateAvailable" yaml:"updateAvailable"`
+	// Constraint is the catalog version constraint; LatestVersion is the newest version it allows
+	Constraint string `json:"constraint" yaml:"constraint"`
+	Policy     string `json:"policy" yaml:"policy"`
+}
+
+// StatusReport is the top-level document written by list and outdated
+type StatusReport struct {
+	SchemaVersion int          `json:"schemaVersion" yaml:"schemaVersion"`
+	Tools         []ToolStatus `json:"tools" yaml:"tools"`
+}
+
+// HistorySchemaVersion identifies the layout of HistoryReport.
+// It is incremented whenever a field is removed or changes meaning.
+const HistorySchemaVersion = 1
+
+// HistoryReport is the document written by history
+type HistoryReport struct {
+	SchemaVersion int            `json:"schemaVersion" yaml:"schemaVersion"`
+	Records       []audit.Record `json:"records" yaml:"records"`
+}
+
+// validOutputFormat reports whether format is a supported --output value
+func validOutputFormat(format string) bool {
+	switch format {
+	case OutputText, OutputJSON, OutputYAML:
+		return true
+	}
+	return false
+}
+
+// toolStatuses builds the status of each tool from the installed list and version cache
+func (a *App) toolStatuses(tools []config.Tool) []ToolStatus {
+	statuses := make([]ToolStatus, 0, len(tools))
+	for _, tool := range tools {
+		status := ToolStatus{
+			Name:       tool.Name,
+			Package:    tool.Package,
+			Source:     tool.SourceName(),
+			Installed:  a.isInstalled(tool),
+			Constraint: tool.Version,
+			Policy:     tool.UpdatePolicy(),
+		}
+
+		if versionInfo, ok := a.versionCache.Get(tool.Package); ok {
+			if status.Installed {
+				status.CurrentVersion = versionInfo.CurrentVersion
+				status.UpdateAvailable = updateOffered(tool, versionInfo)
+			}
+			status.LatestVersion = versionInfo.LatestVersion
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// writeStatusReport encodes the statuses as JSON or YAML
+func writeStatusReport(w io.Writer, format string, statuses []ToolStatus) error {
+	return writeDocument(w, format, StatusReport{
+		SchemaVersion: StatusSchemaVersion,
+		Tools:         statuses,
+	})
+}
+
+// writeDocument encodes a machine-readable document as JSON or YAML
+func writeDocument(w io.Writer, format string, document interface{}) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+	"golang.org/x/term"
+)
+
+// profileMarker precedes the PATH line atm adds to a shell profile
+const profileMarker = "# Added by atm: global npm packages"
+
+// offerUserPrefix handles a package operation that failed because the global
+// directory is not writable. It offers to move npm's global prefix to a
+// directory the user owns and to add its bin directory to PATH, and reports
+// whether the operation should be retried. atm never retries with sudo.
+func (a *App) offerUserPrefix(opErr error) bool {
+	if !errors.Is(opErr, manager.ErrPermission) || a.prefixOffered {
+		return false
+	}
+	a.prefixOffered = true
+
+	if a.spinner != nil && a.spinner.Active() {
+		a.spinner.Stop()
+		defer a.spinner.Start()
+	}
+
+	backend := a.packageManager.Backend()
+	fmt.Println(color.YellowString(i18n.T("prefix.denied")))
+
+	setter, ok := backend.(manager.PrefixSetter)
+	if !ok {
+		fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("prefix.otherBackend", backend.Name())))
+		return false
+	}
+
+	prefix, err := manager.UserPrefix()
+	if err != nil {
+		fmt.Println(color.RedString(i18n.T("prefix.failed", err.Error())))
+		return false
+	}
+	bin := manager.PrefixBin(prefix)
+
+	// Scripts get the steps to run instead of a prompt they cannot answer
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("prefix.manual", prefix, bin)))
+		return false
+	}
+
+	fmt.Println(i18n.T("prefix.explain", prefix))
+	confirmPrompt := promptui.Select{
+		Label: fmt.Sprintf("%s %s", i18n.T("prefix.confirm", prefix, bin), i18n.T("prompts.confirm")),
+		Items: []string{"No", "Yes"},
+	}
+	if choice, _, err := confirmPrompt.Run(); err != nil || choice == 0 {
+		fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("prefix.manual", prefix, bin)))
+		return false
+	}
+
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		fmt.Println(color.RedString(i18n.T("prefix.failed", err.Error())))
+		return false
+	}
+	if err := setter.SetPrefix(a.ctx, prefix); err != nil {
+		fmt.Println(color.RedString(i18n.T("prefix.failed", err.Error())))
+		return false
+	}
+	fmt.Println(color.GreenString("✓ " + i18n.T("prefix.configured", prefix)))
+
+	if !onPath(bin) {
+		addToPath(bin)
+	}
+	return true
+}
+
+// addToPath adds dir to PATH for this process and, outside Windows, in the
+// user's shell profile
+func addToPath(dir string) {
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if runtime.GOOS == "windows" {
+		fmt.Println(color.YellowString(i18n.T("prefix.pathManual", dir)))
+		return
+	}
+
+	profile, line, err := shellProfile(dir)
+	if err == nil {
+		err = appendToProfile(profile, line)
+	}
+	if err != nil {
+		fmt.Println(color.YellowString(i18n.T("prefix.pathFailed", err.Error(), dir)))
+		return
+	}
+	fmt.Println(color.GreenString("✓ " + i18n.T("prefix.pathAdded", dir, profile)))
+}
+
+// shellProfile returns the startup file of the user's shell and the line that
+// adds dir to PATH in it
+func shellProfile(dir string) (string, string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Keep the profile portable by writing paths under the home directory as $HOME
+	shown := dir
+	if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		shown = "$HOME/" + filepath.ToSlash(rel)
+	}
+
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "fish":
+		return filepath.Join(home, ".config", "fish", "config.fish"), "fish_add_path " + shown, nil
+	case "zsh":
+		return filepath.Join(home, ".zshrc"), `export PATH="` + shown + `:$PATH"`, nil
+	case "bash":
+		// Terminal windows on macOS start login shells, which read .bash_profile
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, ".bash_profile"), `export PATH="` + shown + `:$PATH"`, nil
+		}
+		return filepath.Join(home, ".bashrc"), `export PATH="` + shown + `:$PATH"`, nil
+	}
+	return filepath.Join(home, ".profile"), `export PATH="` + shown + `:$PATH"`, nil
+}
+
+// appendToProfile appends a line to a shell profile unless it is already there
+func appendToProfile(profile, line string) error {
+	data, err := os.ReadFile(profile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if strings.Contains(string(data), line) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(profile), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(profile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	block := profileMarker + "\n" + line + "\n"
+	if len(data) > 0 {
+		block = "\n" + block
+		if !strings.HasSuffix(string(data), "\n") {
+			block = "\n" + block
+		}
+	}
+	if _, err := f.WriteString(block); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestShellProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	bashrc := ".bashrc"
+	if runtime.GOOS == "darwin" {
+		bashrc = ".bash_profile"
+	}
+	tests := []struct {
+		shell       string
+		dir         string
+		wantProfile string
+		wantLine    string
+	}{
+		{"/bin/bash", filepath.Join(home, ".npm-global", "bin"), bashrc, `export PATH="$HOME/.npm-global/bin:$PATH"`},
+		{"/usr/bin/zsh", filepath.Join(home, ".npm-global", "bin"), ".zshrc", `export PATH="$HOME/.npm-global/bin:$PATH"`},
+		{"/usr/local/bin/fish", filepath.Join(home, ".local", "bin"), filepath.Join(".config", "fish", "config.fish"), "fish_add_path $HOME/.local/bin"},
+		{"/bin/sh", "/opt/tools/bin", ".profile", `export PATH="/opt/tools/bin:$PATH"`},
+		{"", filepath.Join(home, "bin"), ".profile", `export PATH="$HOME/bin:$PATH"`},
+		{"/bin/zsh", filepath.Join(filepath.Dir(home), "other", "bin"), ".zshrc", `export PATH="` + filepath.Join(filepath.Dir(home), "other", "bin") + `:$PATH"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			t.Setenv("SHELL", tt.shell)
+			profile, line, err := shellProfile(tt.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(home, tt.wantProfile); profile != want {
+				t.Errorf("profile = %s, want %s", profile, want)
+			}
+			if line != tt.wantLine {
+				t.Errorf("line = %s, want %s", line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestAppendToProfile(t *testing.T) {
+	const line = `export PATH="$HOME/.npm-global/bin:$PATH"`
+	block := profileMarker + "\n" + line + "\n"
+	tests := []struct {
+		name     string
+		existing *string
+		want     string
+	}{
+		{"new file", nil, block},
+		{"empty file", ptr(""), block},
+		{"trailing newline", ptr("alias ll='ls -l'\n"), "alias ll='ls -l'\n\n" + block},
+		{"no trailing newline", ptr("alias ll='ls -l'"), "alias ll='ls -l'\n\n" + block},
+		{"line already there", ptr("# mine\n" + line + "\n"), "# mine\n" + line + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The profile of fish lives in a directory that may not exist yet
+			profile := filepath.Join(t.TempDir(), ".config", "fish", "config.fish")
+			if tt.existing != nil {
+				if err := os.MkdirAll(filepath.Dir(profile), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(profile, []byte(*tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Appending twice must not duplicate the block
+			for i := 0; i < 2; i++ {
+				if err := appendToProfile(profile, line); err != nil {
+					t.Fatal(err)
+				}
+			}
+			data, err := os.ReadFile(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("profile = %q, want %q", data, tt.want)
+			}
+			if n := strings.Count(string(data), line); n != 1 {
+				t.Errorf("the line appears %d times", n)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to s
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/history"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+)
+
+// cmdRollback restores the version each named tool had before its last update
+func (a *App) cmdRollback(args []string) int {
+	fs := newFlagSet("rollback")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(names) == 0 {
+		return usageError("cli.usage.rollback")
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	tools, ok := a.resolveTools(names)
+	if !ok {
+		return ExitUsage
+	}
+
+	code := ExitOK
+	for _, tool := range tools {
+		if a.interrupted() {
+			return ExitInterrupted
+		}
+		if !a.isInstalled(tool) {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("cli.notInstalled", tool.Name)))
+			code = ExitFailure
+			continue
+		}
+
+		change, found, err := a.lastChange(tool)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("rollback.historyError", err.Error())))
+			return ExitFailure
+		}
+		if !found {
+			fmt.Fprintln(os.Stderr, color.RedString(i18n.T("rollback.noHistory", tool.Name)))
+			code = ExitFailure
+			continue
+		}
+
+		if err := a.rollbackTool(tool, change); err != nil {
+			a.reportFailure(os.Stderr, "rollback.failed", tool, err)
+			code = failureCode(code, err)
+		}
+	}
+
+	return code
+}
+
+// handleRollback handles the rollback action
+func (a *App) handleRollback() {
+	var tools []config.Tool
+	var changes []history.Change
+	for _, tool := range a.installedTools {
+		change, found, err := a.lastChange(tool)
+		if err != nil {
+			fmt.Println(color.RedString(i18n.T("rollback.historyError", err.Error())))
+			return
+		}
+		if found {
+			tools = append(tools, tool)
+			changes = append(changes, change)
+		}
+	}
+
+	if len(tools) == 0 {
+		fmt.Println(color.YellowString(i18n.T("rollback.noneAvailable")))
+		return
+	}
+
+	items := make([]string, len(tools))
+	for i, tool := range tools {
+		items[i] = fmt.Sprintf("%s (v%s → v%s)", tool.Name, a.currentVersion(tool), changes[i].From)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "▸ {{ . | cyan }}",
+		Inactive: "  {{ . }}",
+		Selected: "▸ {{ . | cyan }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     i18n.T("rollback.select") + " " + i18n.T("prompts.useArrowKeys"),
+		Items:     append(items, i18n.T("menu.exit")),
+		Templates: templates,
+		Size:      10,
+	}
+
+	index, _, err := prompt.Run()
+	if err != nil || index == len(items) {
+		return
+	}
+
+	if err := a.rollbackTool(tools[index], changes[index]); err != nil {
+		a.reportFailure(os.Stdout, "rollback.failed", tools[index], err)
+	}
+}
+
+// rollbackTool reinstalls the version a tool had before a change and drops
+// the change from the history, so the next rollback goes one step further back
+func (a *App) rollbackTool(tool config.Tool, change history.Change) error {
+	previous := a.currentVersion(tool)
+
+	s := a.startSpinner(i18n.T("rollback.rollingBack", tool.Name, change.From))
+
+	err := a.installToolVersion(a.ctx, tool, change.From)
+	s.Stop()
+
+	a.logOperation(audit.ActionRollback, tool, previous, change.From, err)
+	if err != nil {
+		return err
+	}
+
+	if err := a.history.Pop(tool.Package); err != nil {
+		fmt.Fprintln(os.Stderr, color.YellowString(i18n.T("rollback.historyError", err.Error())))
+	}
+
+	fmt.Println(color.GreenString("✓ " + i18n.T("rollback.success", tool.Name, previous, change.From)))
+	return nil
+}
+
+// lastChange returns the most recent recorded version change of a tool
+func (a *App) lastChange(tool config.Tool) (history.Change, bool, error) {
+	if a.history == nil {
+		return history.Change{}, false, nil
+	}
+	return a.history.Last(tool.Package)
+}
+
+// recordChange stores a version change of a tool so it can be rolled back.
+// A failure is reported but does not fail the operation that changed the version.
+func (a *App) recordChange(tool config.Tool, from, to string) {
+	if a.history == nil || from == "" || to == "" || from == to {
+		return
+	}
+
+	if err := a.history.Record(tool.Package, from, to); err != nil {
+		fmt.Fprintln(os.Stderr, color.YellowString(i18n.T("rollback.recordFailed", tool.Name, err.Error())))
+	}
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/fatih/color"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+	versionpkg "github.com/xiaoxu123195/atm/pkg/version"
+)
+
+// cmdSelfUpdate replaces the atm executable with the binary of the latest
+// release, or of the release given with --version. --rollback restores the
+// executable the last self-update replaced.
+func (a *App) cmdSelfUpdate(args []string) int {
+	fs := newFlagSet("self-update")
+	version := fs.String("version", "", "install this version instead of the latest")
+	rollback := fs.Bool("rollback", false, "restore the executable replaced by the last self-update")
+	dryRun := fs.Bool("dry-run", false, "show what would change without changing it")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(rest) > 0 || *rollback && *version != "" {
+		return usageError("cli.usage.selfUpdate")
+	}
+
+	if *rollback {
+		return a.rollbackSelf(*dryRun)
+	}
+
+	// The GitHub settings apply if the configuration loads, but a broken
+	// configuration must not keep atm from updating itself
+	cfg, _ := config.Load()
+	a.configureGitHub(cfg)
+	return a.selfUpdate(*version, *dryRun)
+}
+
+// selfUpdate downloads the atm binary of a release, verifies its checksum and
+// replaces the running executable with it. An empty version means the
+// latest release, which is only installed if it is newer.
+func (a *App) selfUpdate(version string, dryRun bool) int {
+	executable, err := versionpkg.Executable()
+	if err != nil {
+		return selfUpdateFailed(err)
+	}
+
+	s := a.startSpinner(i18n.T("selfUpdate.checking"))
+	release, err := a.versionChecker.Release(a.ctx, version)
+	s.Stop()
+	if err != nil {
+		return selfUpdateFailed(err)
+	}
+
+	latest := release.Version()
+	// Never downgrade unless a version was asked for
+	if latest == a.version || version == "" && !semver.Newer(latest, a.version) {
+		fmt.Println(color.GreenString(i18n.T("selfUpdate.upToDate", a.version)))
+		return ExitOK
+	}
+
+	if dryRun {
+		fmt.Println(i18n.T("selfUpdate.dryRun", executable, a.version, latest))
+		fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("selfUpdate.asset", versionpkg.AssetName(runtime.GOOS, runtime.GOARCH), release.HTMLURL)))
+		return ExitOK
+	}
+
+	s = a.startSpinner(i18n.T("selfUpdate.downloading", latest))
+	download, err := a.versionChecker.Download(a.ctx, release, filepath.Dir(executable))
+	s.Stop()
+	if err != nil {
+		return selfUpdateFailed(err)
+	}
+
+	if err := versionpkg.Replace(executable, download); err != nil {
+		os.Remove(download)
+		return selfUpdateFailed(err)
+	}
+
+	fmt.Println(color.GreenString("✓ " + i18n.T("selfUpdate.success", a.version, latest)))
+	fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("selfUpdate.backup", versionpkg.BackupPath(executable))))
+	return ExitOK
+}
+
+// rollbackSelf restores the executable the last self-update replaced
+func (a *App) rollbackSelf(dryRun bool) int {
+	executable, err := versionpkg.Executable()
+	if err != nil {
+		return selfUpdateFailed(err)
+	}
+	backup := versionpkg.BackupPath(executable)
+
+	if dryRun {
+		if _, err := os.Stat(backup); err != nil {
+			return selfUpdateFailed(versionpkg.ErrNoBackup)
+		}
+		fmt.Println(i18n.T("selfUpdate.rollbackDryRun", executable, backup))
+		return ExitOK
+	}
+
+	if err := versionpkg.Rollback(executable); err != nil {
+		return selfUpdateFailed(err)
+	}
+	fmt.Println(color.GreenString("✓ " + i18n.T("selfUpdate.rolledBack", executable)))
+	return ExitOK
+}
+
+// selfUpdateFailed prints why a self-update failed and how to fix it, and
+// returns the exit code
+func selfUpdateFailed(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return reportNothingChanged()
+	}
+
+	message, hintKey, code := err.Error(), "", exitCode(err)
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, versionpkg.ErrNoChecksum):
+		message, hintKey = i18n.T("selfUpdate.noChecksum"), "selfUpdate.hint.noChecksum"
+	case errors.Is(err, versionpkg.ErrNoBackup):
+		message, code = i18n.T("selfUpdate.noBackup"), ExitFailure
+	case errors.Is(err, github.ErrChecksumMismatch):
+		message, hintKey = i18n.T("errors.checksum"), "errors.hint.checksum"
+	case errors.Is(err, github.ErrRateLimited):
+		hintKey, code = "version.hint.rateLimited", ExitNetwork
+	case github.IsUnauthorized(err):
+		hintKey, code = "version.hint.tokenRejected", ExitFailure
+	case errors.Is(err, os.ErrPermission):
+		hintKey, code = "selfUpdate.hint.permission", ExitPermission
+	case github.IsNotFound(err):
+		message, hintKey, code = i18n.T("selfUpdate.notFound"), "selfUpdate.hint.notFound", ExitNotFound
+	case errors.As(err, &urlErr):
+		hintKey, code = "errors.hint.network", ExitNetwork
+	}
+
+	fmt.Fprintln(os.Stderr, color.RedString("✗ "+i18n.T("selfUpdate.failed", message)))
+	if hintKey != "" {
+		fmt.Fprintln(os.Stderr, color.New(color.FgHiBlack).Sprint("  "+i18n.T("errors.hint", i18n.T(hintKey))))
+	}
+	return code
+}
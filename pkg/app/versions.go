@@ -0,0 +1,311 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+	"github.com/xiaoxu123195/atm/pkg/audit"
+	"github.com/xiaoxu123195/atm/pkg/config"
+	"github.com/xiaoxu123195/atm/pkg/i18n"
+	"github.com/xiaoxu123195/atm/pkg/manager"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+)
+
+// versionListLimit is how many versions atm versions shows without --all
+const versionListLimit = 20
+
+// versionChoice is a published version offered when choosing a version
+type versionChoice struct {
+	Version    string
+	Published  time.Time
+	Tags       []string
+	Deprecated bool
+}
+
+// cmdVersions lists the published versions of a tool, newest first
+func (a *App) cmdVersions(args []string) int {
+	fs := newFlagSet("versions")
+	all := fs.Bool("all", false, "list every published version")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExitCode(err)
+	}
+	if len(names) != 1 {
+		return usageError("cli.usage.versions")
+	}
+
+	if err := a.prepare(); err != nil {
+		return startFailed(err)
+	}
+
+	tools, ok := a.resolveTools(names)
+	if !ok {
+		return ExitUsage
+	}
+	tool := tools[0]
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("versions.fetching", tool.Name)
+	s.Start()
+	choices, err := a.fetchVersionChoices(tool)
+	s.Stop()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.RedString(i18n.T("versions.fetchFailed", tool.Name, a.describeError(err))))
+		printHint(os.Stderr, err)
+		return exitCode(err)
+	}
+	if len(choices) == 0 {
+		fmt.Println(color.YellowString(i18n.T("versions.none", tool.Name)))
+		return ExitOK
+	}
+
+	hidden := 0
+	if !*all && len(choices) > versionListLimit {
+		hidden = len(choices) - versionListLimit
+		choices = choices[:versionListLimit]
+	}
+
+	current := a.currentVersion(tool)
+	for _, choice := range choices {
+		fmt.Printf("%s %s\n", color.BlueString("•"), formatVersionChoice(choice, current))
+	}
+	if hidden > 0 {
+		fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("versions.more", hidden)))
+	}
+
+	return ExitOK
+}
+
+// handleChooseVersion handles the choose version action: pick a tool, then
+// install any published version of it, including older ones
+func (a *App) handleChooseVersion() {
+	items := make([]string, len(a.config.Tools))
+	for i, tool := range a.config.Tools {
+		items[i] = fmt.Sprintf("%s (%s)", tool.Name, tool.Package)
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   "▸ {{ . | cyan }}",
+		Inactive: "  {{ . }}",
+		Selected: "▸ {{ . | cyan }}",
+	}
+
+	prompt := promptui.Select{
+		Label:     i18n.T("versions.selectTool") + " " + i18n.T("prompts.useArrowKeys"),
+		Items:     append(items, i18n.T("menu.exit")),
+		Templates: templates,
+		Size:      10,
+	}
+
+	index, _, err := prompt.Run()
+	if err != nil || index == len(items) {
+		return
+	}
+	tool := a.config.Tools[index]
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("versions.fetching", tool.Name)
+	s.Start()
+	choices, err := a.fetchVersionChoices(tool)
+	s.Stop()
+
+	if err != nil {
+		fmt.Println(color.RedString(i18n.T("versions.fetchFailed", tool.Name, a.describeError(err))))
+		printHint(os.Stdout, err)
+		return
+	}
+	if len(choices) == 0 {
+		fmt.Println(color.YellowString(i18n.T("versions.none", tool.Name)))
+		return
+	}
+
+	current := a.currentVersion(tool)
+	items = make([]string, len(choices))
+	for i, choice := range choices {
+		items[i] = formatVersionChoice(choice, current)
+	}
+
+	prompt = promptui.Select{
+		Label:     i18n.T("versions.select", tool.Name) + " " + i18n.T("prompts.useArrowKeys"),
+		Items:     append(items, i18n.T("menu.exit")),
+		Templates: templates,
+		Size:      15,
+	}
+
+	index, _, err = prompt.Run()
+	if err != nil || index == len(items) {
+		return
+	}
+	version := choices[index].Version
+
+	// Downgrades are confirmed, since the next update --all would undo them
+	if current != "" && semver.Newer(current, version) {
+		confirmPrompt := promptui.Select{
+			Label: fmt.Sprintf("%s %s", i18n.T("versions.confirmDowngrade", tool.Name, current, version), i18n.T("prompts.confirm")),
+			Items: []string{"No", "Yes"},
+		}
+
+		choice, _, err := confirmPrompt.Run()
+		if err != nil || choice == 0 {
+			return
+		}
+	}
+
+	if err := a.changeVersion(tool, version); err != nil {
+		a.reportFailure(os.Stdout, "install.failed", tool, err)
+	}
+}
+
+// changeVersion installs an exact version of a tool and reports it as an
+// install, upgrade or downgrade. A tool already at that version is left alone.
+func (a *App) changeVersion(tool config.Tool, version string) error {
+	current := a.currentVersion(tool)
+	if current == version {
+		fmt.Println(color.GreenString(i18n.T("versions.alreadyAt", tool.Name, version)))
+		return nil
+	}
+	downgrade := current != "" && semver.Newer(current, version)
+
+	var message string
+	switch {
+	case current == "":
+		message = i18n.T("versions.installing", tool.Name, version)
+	case downgrade:
+		message = i18n.T("versions.downgrading", tool.Name, current, version)
+	default:
+		message = i18n.T("versions.upgrading", tool.Name, current, version)
+	}
+	s := a.startSpinner(message)
+
+	err := a.installToolVersion(a.ctx, tool, version)
+	s.Stop()
+
+	a.logOperation(audit.ActionInstall, tool, current, version, err)
+	if err != nil {
+		return err
+	}
+	a.recordChange(tool, current, version)
+
+	switch {
+	case current == "":
+		fmt.Println(color.GreenString("✓ " + i18n.T("versions.installed", tool.Name, version)))
+	case downgrade:
+		fmt.Println(color.GreenString("✓ " + i18n.T("versions.downgraded", tool.Name, version)))
+		if tool.UpdatePolicy() != config.PolicyPinned {
+			fmt.Println(color.New(color.FgHiBlack).Sprint(i18n.T("versions.pinHint", tool.Name)))
+		}
+	default:
+		fmt.Println(color.GreenString("✓ " + i18n.T("versions.upgraded", tool.Name, version)))
+	}
+	return nil
+}
+
+// currentVersion returns the installed version of a tool, or "" if it is not installed
+func (a *App) currentVersion(tool config.Tool) string {
+	if !a.isInstalled(tool) {
+		return ""
+	}
+	versionInfo, _ := a.versionCache.Get(tool.Package)
+	return versionInfo.CurrentVersion
+}
+
+// fetchVersionChoices reads the published versions of a tool from the registry
+func (a *App) fetchVersionChoices(tool config.Tool) ([]versionChoice, error) {
+	if !tool.IsNpm() {
+		return nil, fmt.Errorf("%s", i18n.T("versions.npmOnly", tool.SourceName()))
+	}
+	packument, err := a.packageManager.GetFullPackument(a.ctx, tool.Package)
+	if err != nil {
+		return nil, err
+	}
+	return versionChoices(packument), nil
+}
+
+// versionChoices lists the valid versions of a packument, newest first,
+// with their publish times and the dist-tags that point at them
+func versionChoices(packument *manager.Packument) []versionChoice {
+	tags := make(map[string][]string)
+	for tag, version := range packument.DistTags {
+		tags[version] = append(tags[version], tag)
+	}
+
+	type parsedVersion struct {
+		text    string
+		version semver.Version
+	}
+	var versions []parsedVersion
+	for text := range packument.Versions {
+		if version, err := semver.Parse(text); err == nil {
+			versions = append(versions, parsedVersion{text, version})
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i].version, versions[j].version) > 0
+	})
+
+	choices := make([]versionChoice, 0, len(versions))
+	for _, v := range versions {
+		versionTags := tags[v.text]
+		sort.Strings(versionTags)
+		choices = append(choices, versionChoice{
+			Version:    v.text,
+			Published:  packument.PublishTime(v.text),
+			Tags:       versionTags,
+			Deprecated: packument.Versions[v.text].Deprecated != "",
+		})
+	}
+	return choices
+}
+
+// formatVersionChoice formats a version with its publish date and labels
+func formatVersionChoice(choice versionChoice, current string) string {
+	text := fmt.Sprintf("v%-16s", choice.Version)
+	if !choice.Published.IsZero() {
+		text += " " + choice.Published.Format("2006-01-02")
+	}
+
+	labels := append([]string{}, choice.Tags...)
+	if choice.Version == current {
+		labels = append(labels, i18n.T("versions.tagInstalled"))
+	}
+	if choice.Deprecated {
+		labels = append(labels, i18n.T("versions.tagDeprecated"))
+	}
+	if len(labels) > 0 {
+		text += " (" + strings.Join(labels, ", ") + ")"
+	}
+	return text
+}
+
+// splitToolSpec splits "tool@version" into the tool name and the version.
+// The "@" that starts a scoped package name is not a separator.
+func splitToolSpec(arg string) (string, string) {
+	if i := strings.LastIndex(arg, "@"); i > 0 {
+		return arg[:i], arg[i+1:]
+	}
+	return arg, ""
+}
+
+// installRequestedVersion resolves a version, range or dist-tag given on the
+// command line and installs the exact version it selects
+func (a *App) installRequestedVersion(tool config.Tool, spec string) error {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = " " + i18n.T("versions.resolving", tool.Name, spec)
+	s.Start()
+	version, err := a.resolveVersion(a.ctx, tool, strings.TrimPrefix(spec, "v"))
+	s.Stop()
+
+	if err != nil {
+		a.logOperation(audit.ActionInstall, tool, a.currentVersion(tool), spec, err)
+		return err
+	}
+	return a.changeVersion(tool, version)
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// FileName is the name of the audit log in the state directory
+const FileName = "audit.jsonl"
+
+// Actions recorded in the audit log
+const (
+	ActionInstall   = "install"
+	ActionUpdate    = "update"
+	ActionUninstall = "uninstall"
+	ActionRollback  = "rollback"
+)
+
+// maxLineSize bounds a single record when reading the log; longer lines are skipped
+const maxLineSize = 1 << 20
+
+// maxErrorSize bounds the error message stored in a record, which may hold the
+// whole output of a failed package manager
+const maxErrorSize = 4 << 10
+
+// Record is one operation in the audit log
+type Record struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	User    string    `json:"user" yaml:"user"`
+	Action  string    `json:"action" yaml:"action"`
+	Tool    string    `json:"tool" yaml:"tool"`
+	Package string    `json:"package" yaml:"package"`
+	// FromVersion is empty for installs; ToVersion is empty for uninstalls
+	FromVersion string `json:"fromVersion" yaml:"fromVersion"`
+	ToVersion   string `json:"toVersion" yaml:"toVersion"`
+	Backend     string `json:"backend" yaml:"backend"`
+	Success     bool   `json:"success" yaml:"success"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Filter selects records when reading the log. Zero fields match everything.
+type Filter struct {
+	Package string
+	Since   time.Time
+	Until   time.Time
+}
+
+// matches reports whether a record passes the filter
+func (f Filter) matches(r Record) bool {
+	if f.Package != "" && r.Package != f.Package {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Log is an append-only JSON Lines file of operations
+type Log struct {
+	path string
+}
+
+// NewLog creates a log backed by the file at path
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Append writes a record as a single line. Time and User are filled in when
+// empty and Error is truncated to maxErrorSize.
+func (l *Log) Append(r Record) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	if r.User == "" {
+		r.User = CurrentUser()
+	}
+	r.Error = truncate(r.Error, maxErrorSize)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+
+	// O_APPEND makes each single write land at the end, even with concurrent writers
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the records that match the filter, oldest first. A missing
+// log is empty; lines that cannot be decoded or exceed maxLineSize are skipped.
+func (l *Log) Read(filter Filter) ([]Record, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	reader := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+
+		var r Record
+		if json.Unmarshal(line, &r) != nil {
+			continue
+		}
+		if filter.matches(r) {
+			records = append(records, r)
+		}
+	}
+}
+
+// readLine returns the next line of r. A line longer than maxLineSize is
+// consumed and returned as nil, so that it fails to decode.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	overlong := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !overlong && len(line)+len(chunk) > maxLineSize+1 {
+			overlong, line = true, nil
+		}
+		if !overlong {
+			line = append(line, chunk...)
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && (len(line) > 0 || overlong):
+			// The last line has no newline
+			return line, nil
+		default:
+			return line, err
+		}
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	const ellipsis = "…"
+	if len(s) <= n {
+		return s
+	}
+	cut := n - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
+
+// CurrentUser returns the name of the user running atm
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newTestLog creates a log in a temporary directory that does not exist yet
+func newTestLog(t *testing.T) *Log {
+	t.Helper()
+	return NewLog(filepath.Join(t.TempDir(), "state", FileName))
+}
+
+// packages returns the packages of records
+func packages(records []Record) []string {
+	var list []string
+	for _, r := range records {
+		list = append(list, r.Package)
+	}
+	return list
+}
+
+func TestAppendRead(t *testing.T) {
+	log := newTestLog(t)
+	records := []Record{
+		{Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), User: "dev", Action: ActionInstall, Tool: "Codex", Package: "@openai/codex", ToVersion: "0.5.0", Backend: "npm", Success: true},
+		{Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), User: "dev", Action: ActionUpdate, Tool: "Codex", Package: "@openai/codex", FromVersion: "0.5.0", ToVersion: "0.6.0", Backend: "pnpm", Error: "EACCES"},
+	}
+	for _, r := range records {
+		if err := log.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Read = %+v, want %+v", got, records)
+	}
+}
+
+func TestAppendFillsDefaults(t *testing.T) {
+	log := newTestLog(t)
+	before := time.Now()
+	if err := log.Append(Record{Action: ActionUninstall, Package: "a", Error: strings.Repeat("é", maxErrorSize)}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := log.Read(Filter{})
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Read = %v, %v", records, err)
+	}
+	r := records[0]
+	if r.Time.Before(before.Add(-time.Second)) || r.User == "" {
+		t.Errorf("time = %v, user = %q; want them filled in", r.Time, r.User)
+	}
+	if len(r.Error) > maxErrorSize || !utf8.ValidString(r.Error) || !strings.HasSuffix(r.Error, "…") {
+		t.Errorf("error of %d bytes was not truncated to %d on a character boundary", len(r.Error), maxErrorSize)
+	}
+}
+
+func TestReadFilter(t *testing.T) {
+	log := newTestLog(t)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	for _, r := range []Record{
+		{Time: day(1), Package: "a"},
+		{Time: day(2), Package: "b"},
+		{Time: day(3), Package: "a"},
+		{Time: day(4), Package: "b"},
+	} {
+		if err := log.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"everything", Filter{}, []string{"a", "b", "a", "b"}},
+		{"package", Filter{Package: "a"}, []string{"a", "a"}},
+		{"unknown package", Filter{Package: "c"}, nil},
+		{"since is inclusive", Filter{Since: day(2)}, []string{"b", "a", "b"}},
+		{"until is exclusive", Filter{Until: day(3)}, []string{"a", "b"}},
+		{"since and until", Filter{Since: day(2), Until: day(4)}, []string{"b", "a"}},
+		{"empty range", Filter{Since: day(3), Until: day(3)}, nil},
+		{"package and range", Filter{Package: "b", Since: day(3)}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := log.Read(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := packages(records); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSkipsCorruptLines(t *testing.T) {
+	lines := []string{
+		`{"time":"2024-01-01T00:00:00Z","package":"a"}`,
+		`not json`,
+		`{"time":"2024-01-02T00:00:00Z","package":"b"`,
+		`{"time":"2024-01-03T00:00:00Z","package":"c","error":"` + strings.Repeat("x", maxLineSize) + `"}`,
+		``,
+		`{"time":"2024-01-04T00:00:00Z","package":"d"}`,
+		`{"time":"2024-01-05T00:00:00Z","package":"e"}`,
+	}
+	path := filepath.Join(t.TempDir(), FileName)
+	// The last line has no newline, as after an interrupted write
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := NewLog(path).Read(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := packages(records), []string{"a", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("packages = %v, want %v", got, want)
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	records, err := newTestLog(t).Read(Filter{})
+	if err != nil || records != nil {
+		t.Errorf("Read = %v, %v; want an empty log", records, err)
+	}
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileVersion identifies the layout of the on-disk cache file
+const fileVersion = 1
+
+// VersionInfo stores version information for a tool.
+// Only the registry fields are persisted; the installed version is read from
+// the global store on every launch.
+type VersionInfo struct {
+	CurrentVersion string `json:"-"`
+	LatestVersion  string `json:"latestVersion"`
+	// FetchedAt is when LatestVersion was read from the registry
+	FetchedAt time.Time `json:"fetchedAt"`
+	// ETag identifies the packument LatestVersion was read from
+	ETag string `json:"etag,omitempty"`
+	// Constraint is the catalog version constraint LatestVersion satisfies.
+	// Empty means LatestVersion is the latest release.
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// Fresh reports whether the latest version was fetched within ttl
+func (v VersionInfo) Fresh(ttl time.Duration) bool {
+	return v.LatestVersion != "" && time.Since(v.FetchedAt) < ttl
+}
+
+// cacheFile is the on-disk representation of a VersionCache
+type cacheFile struct {
+	Version int                    `json:"version"`
+	Entries map[string]VersionInfo `json:"entries"`
+}
+
+// VersionCache is a concurrency-safe store of VersionInfo keyed by package name
+type VersionCache struct {
+	mu      sync.RWMutex
+	entries map[string]VersionInfo
+}
+
+// NewVersionCache creates an empty version cache
+func NewVersionCache() *VersionCache {
+	return &VersionCache{
+		entries: make(map[string]VersionInfo),
+	}
+}
+
+// Get returns a copy of the cached info for a package
+func (c *VersionCache) Get(packageName string) (VersionInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	info, ok := c.entries[packageName]
+	return info, ok
+}
+
+// Set replaces the cached info for a package
+func (c *VersionCache) Set(packageName string, info VersionInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[packageName] = info
+}
+
+// SetCurrent records the installed version, keeping the latest version
+func (c *VersionCache) SetCurrent(packageName, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info := c.entries[packageName]
+	info.CurrentVersion = version
+	c.entries[packageName] = info
+}
+
+// SetLatest records the newest version allowed by constraint, its ETag and
+// when it was fetched, keeping the installed version
+func (c *VersionCache) SetLatest(packageName, version, constraint, etag string, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info := c.entries[packageName]
+	info.LatestVersion = version
+	info.Constraint = constraint
+	info.ETag = etag
+	info.FetchedAt = fetchedAt
+	c.entries[packageName] = info
+}
+
+// Invalidate drops the cached info for a package so it is fetched again
+func (c *VersionCache) Invalidate(packageName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, packageName)
+}
+
+// Load merges the registry fields stored in a cache file. A missing file is not an error.
+func (c *VersionCache) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Version != fileVersion {
+		// Written by an incompatible release; start over
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for packageName, stored := range file.Entries {
+		info := c.entries[packageName]
+		info.LatestVersion = stored.LatestVersion
+		info.FetchedAt = stored.FetchedAt
+		info.ETag = stored.ETag
+		info.Constraint = stored.Constraint
+		c.entries[packageName] = info
+	}
+	return nil
+}
+
+// Save writes the entries that have a latest version to a cache file.
+// The file is replaced atomically so concurrent atm processes never read a partial file.
+func (c *VersionCache) Save(path string) error {
+	c.mu.RLock()
+	file := cacheFile{
+		Version: fileVersion,
+		Entries: make(map[string]VersionInfo, len(c.entries)),
+	}
+	for packageName, info := range c.entries {
+		if info.LatestVersion != "" {
+			file.Entries[packageName] = info
+		}
+	}
+	c.mu.RUnlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestVersionCacheConcurrentAccess runs every operation from many goroutines
+// at once; run it with go test -race
+func TestVersionCacheConcurrentAccess(t *testing.T) {
+	c := NewVersionCache()
+	path := filepath.Join(t.TempDir(), "versions.json")
+	packages := []string{"a", "b", "c", "@scope/d"}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				name := packages[(worker+i)%len(packages)]
+				switch i % 6 {
+				case 0:
+					c.SetLatest(name, fmt.Sprintf("1.%d.0", i), "", `"etag"`, time.Now())
+				case 1:
+					c.SetCurrent(name, fmt.Sprintf("1.%d.0", worker))
+				case 2:
+					c.Get(name)
+				case 3:
+					c.Invalidate(name)
+				case 4:
+					if err := c.Save(path); err != nil {
+						t.Error(err)
+					}
+				case 5:
+					if err := c.Load(path); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
+
+func TestVersionCacheSetKeepsOtherFields(t *testing.T) {
+	c := NewVersionCache()
+	fetchedAt := time.Now()
+	c.SetCurrent("pkg", "1.0.0")
+	c.SetLatest("pkg", "1.2.0", "^1.0.0", `"e1"`, fetchedAt)
+
+	info, ok := c.Get("pkg")
+	if !ok {
+		t.Fatal("pkg is not cached")
+	}
+	want := VersionInfo{CurrentVersion: "1.0.0", LatestVersion: "1.2.0", Constraint: "^1.0.0", ETag: `"e1"`, FetchedAt: fetchedAt}
+	if info != want {
+		t.Errorf("Get = %+v, want %+v", info, want)
+	}
+
+	c.SetCurrent("pkg", "1.2.0")
+	if info, _ := c.Get("pkg"); info.LatestVersion != "1.2.0" || info.CurrentVersion != "1.2.0" {
+		t.Errorf("SetCurrent changed the latest version: %+v", info)
+	}
+
+	c.Invalidate("pkg")
+	if _, ok := c.Get("pkg"); ok {
+		t.Error("pkg is still cached after Invalidate")
+	}
+}
+
+func TestVersionCacheSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "versions.json")
+	fetchedAt := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	saved := NewVersionCache()
+	saved.SetCurrent("installed-only", "1.0.0")
+	saved.SetCurrent("pkg", "1.0.0")
+	saved.SetLatest("pkg", "1.2.0", "", `"e1"`, fetchedAt)
+	if err := saved.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewVersionCache()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := loaded.Get("pkg")
+	if !ok || info.LatestVersion != "1.2.0" || info.ETag != `"e1"` || !info.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("loaded %+v, want the saved registry fields", info)
+	}
+	if info.CurrentVersion != "" {
+		t.Errorf("the installed version %q was persisted", info.CurrentVersion)
+	}
+	if _, ok := loaded.Get("installed-only"); ok {
+		t.Error("an entry without a latest version was saved")
+	}
+	if err := NewVersionCache().Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Load of a missing file = %v, want nil", err)
+	}
+}
+
+func TestVersionInfoFresh(t *testing.T) {
+	tests := []struct {
+		name string
+		info VersionInfo
+		want bool
+	}{
+		{"recent", VersionInfo{LatestVersion: "1.0.0", FetchedAt: time.Now().Add(-time.Minute)}, true},
+		{"expired", VersionInfo{LatestVersion: "1.0.0", FetchedAt: time.Now().Add(-2 * time.Hour)}, false},
+		{"no version", VersionInfo{FetchedAt: time.Now()}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Fresh(time.Hour); got != tt.want {
+			t.Errorf("%s: Fresh = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
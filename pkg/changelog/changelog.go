@@ -0,0 +1,198 @@
+// Package changelog gathers the release notes of the versions between an
+// installed version of a package and a newer one, from the releases of its
+// GitHub repository or from its CHANGELOG file
+package changelog
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+)
+
+// Entry is the release notes of one version
+type Entry struct {
+	Version string
+	// Title is the name of the release, empty if it only repeats the tag
+	Title string
+	// Date is when the version was released, zero if unknown
+	Date time.Time
+	// Body is the notes in Markdown
+	Body string
+	// URL is the page of the release, empty for CHANGELOG sections
+	URL string
+}
+
+// tagVersion matches the version at the end of a release tag such as v1.2.3,
+// 1.2.3-beta.1 or @scope/name@1.2.3
+var tagVersion = regexp.MustCompile(`v?(\d+(?:\.\d+){0,2}(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)$`)
+
+// headingVersion matches the version in a CHANGELOG heading such as
+// "## 1.2.3", "## [1.2.3] - 2024-01-31" or "# v1.2.3 (2024-01-31)"
+var headingVersion = regexp.MustCompile(`(?:^|[\s\[(@])v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)(?:$|[\s\]),:])`)
+
+// heading matches an ATX Markdown heading
+var heading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+
+// FromReleases selects the releases of the versions newer than from, up to
+// and including to, newest first. Prereleases are skipped unless to is one,
+// and so are the releases a monorepo tags for its other packages
+// (other-package@1.2.3).
+func FromReleases(releases []github.Release, packageName, from, to string) []Entry {
+	lower, upper, ok := bounds(from, to)
+	if !ok {
+		return nil
+	}
+
+	var entries []Entry
+	for _, release := range releases {
+		version, ok := releaseVersion(release.TagName, packageName)
+		if !ok || !inRange(version, lower, upper) || release.Prerelease && upper.Prerelease == "" {
+			continue
+		}
+
+		title := strings.TrimSpace(release.Name)
+		if title == release.TagName || strings.TrimPrefix(title, "v") == version.String() {
+			title = ""
+		}
+		entries = append(entries, Entry{
+			Version: version.String(),
+			Title:   title,
+			Date:    release.PublishedAt,
+			Body:    release.Body,
+			URL:     release.HTMLURL,
+		})
+	}
+	sortNewestFirst(entries)
+	return entries
+}
+
+// Reaches reports whether a page of releases includes one of a version no
+// newer than from, so older pages hold no notes between from and a newer
+// version
+func Reaches(releases []github.Release, packageName, from string) bool {
+	lower, err := parse(from)
+	if err != nil {
+		return false
+	}
+	for _, release := range releases {
+		if version, ok := releaseVersion(release.TagName, packageName); ok && semver.Compare(version, lower) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse reads the sections of a Markdown CHANGELOG whose headings name a
+// version newer than from, up to and including to, newest first. The level
+// of the first heading naming a version is the level of every version
+// heading; a section ends at the next heading of the same or a higher level.
+func Parse(markdown, from, to string) []Entry {
+	lower, upper, ok := bounds(from, to)
+	if !ok {
+		return nil
+	}
+
+	var entries []Entry
+	var current *Entry
+	var body []string
+	level := 0
+	finish := func() {
+		if current != nil {
+			current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+			entries = append(entries, *current)
+		}
+		current, body = nil, nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		match := heading.FindStringSubmatch(line)
+		if match == nil || level > 0 && len(match[1]) > level {
+			if current != nil {
+				body = append(body, line)
+			}
+			continue
+		}
+
+		versionMatch := headingVersion.FindStringSubmatch(match[2])
+		if versionMatch == nil {
+			// A heading of the same or a higher level that names no version,
+			// such as "## Unreleased", ends the section too
+			if level > 0 {
+				finish()
+			}
+			continue
+		}
+		if level == 0 {
+			level = len(match[1])
+		}
+		finish()
+
+		version, err := parse(versionMatch[1])
+		if err != nil || !inRange(version, lower, upper) || version.Prerelease != "" && upper.Prerelease == "" {
+			continue
+		}
+		current = &Entry{Version: version.String()}
+	}
+	finish()
+
+	sortNewestFirst(entries)
+	return entries
+}
+
+// releaseVersion reads the version of a release tag, and reports false if
+// the tag names no version or names one of another package of a monorepo
+func releaseVersion(tag, packageName string) (semver.Version, bool) {
+	if i := strings.LastIndex(tag, "@"); i > 0 {
+		name := tag[:i]
+		if name != packageName && name != packageName[strings.LastIndex(packageName, "/")+1:] {
+			return semver.Version{}, false
+		}
+	}
+
+	match := tagVersion.FindStringSubmatch(tag)
+	if match == nil {
+		return semver.Version{}, false
+	}
+	version, err := parse(match[1])
+	return version, err == nil
+}
+
+// bounds parses the versions between which notes are gathered
+func bounds(from, to string) (lower, upper semver.Version, ok bool) {
+	lower, err := parse(from)
+	if err != nil {
+		return lower, upper, false
+	}
+	upper, err = parse(to)
+	return lower, upper, err == nil
+}
+
+// inRange reports whether lower < version <= upper
+func inRange(version, lower, upper semver.Version) bool {
+	return semver.Compare(version, lower) > 0 && semver.Compare(version, upper) <= 0
+}
+
+// parse parses a version, completing versions such as 1.2 that Python and
+// Go packages use into 1.2.0
+func parse(version string) (semver.Version, error) {
+	version = strings.TrimPrefix(version, "v")
+	core, rest := version, ""
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		core, rest = version[:i], version[i:]
+	}
+	for strings.Count(core, ".") < 2 {
+		core += ".0"
+	}
+	return semver.Parse(core + rest)
+}
+
+// sortNewestFirst sorts entries by version, newest first
+func sortNewestFirst(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return semver.Compare(semver.MustParse(entries[i].Version), semver.MustParse(entries[j].Version)) > 0
+	})
+}
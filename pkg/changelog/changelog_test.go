@@ -0,0 +1,185 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// versions returns the versions of entries
+func versions(entries []Entry) []string {
+	var list []string
+	for _, entry := range entries {
+		list = append(list, entry.Version)
+	}
+	return list
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		from, to string
+		want     []string
+		bodies   []string
+	}{
+		{
+			name:     "second-level headings",
+			markdown: "# Changelog\n\n## 1.2.0\n\n- Add --json\n\n## 1.1.0\n\n- Faster startup\n\n## 1.0.0\n\n- Stable\n",
+			from:     "1.0.0", to: "1.2.0",
+			want:   []string{"1.2.0", "1.1.0"},
+			bodies: []string{"- Add --json", "- Faster startup"},
+		},
+		{
+			name:     "subsections stay in the body",
+			markdown: "## 1.1.0\n\n### Features\n\n- Plugins\n\n### Fixes\n\n- Crash\n\n## 1.0.0\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"### Features\n\n- Plugins\n\n### Fixes\n\n- Crash"},
+		},
+		{
+			name:     "first-level headings with v and dates",
+			markdown: "# v1.1.0 (2024-02-01)\n\nNew\n\n# v1.0.0 (2024-01-01)\n\nOld\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"New"},
+		},
+		{
+			name:     "keep a changelog",
+			markdown: "## [Unreleased]\n\n- Next\n\n## [1.1.0] - 2024-02-01\n\n### Added\n\n- Plugins\n\n## [1.0.0] - 2024-01-01\n\n- Stable\n\n[1.1.0]: https://example.com\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"### Added\n\n- Plugins"},
+		},
+		{
+			name:     "unreleased heading ends a section",
+			markdown: "## 1.1.0\n\n- Shipped\n\n## Unreleased\n\n- Not shipped\n\n## 1.0.0\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"- Shipped"},
+		},
+		{
+			name:     "two-part versions",
+			markdown: "## 0.46\n\n- Newest\n\n## 0.45\n\n- Older\n\n## 0.44\n",
+			from:     "0.44", to: "0.46",
+			want:   []string{"0.46.0", "0.45.0"},
+			bodies: []string{"- Newest", "- Older"},
+		},
+		{
+			name:     "prereleases skipped",
+			markdown: "## 1.1.0\n\n- Final\n\n## 1.1.0-beta.1\n\n- Beta\n\n## 1.0.0\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"- Final"},
+		},
+		{
+			name:     "prereleases kept up to a prerelease",
+			markdown: "## 2.0.0-beta.2\n\n- Beta 2\n\n## 2.0.0-beta.1\n\n- Beta 1\n\n## 1.0.0\n",
+			from:     "1.0.0", to: "2.0.0-beta.2",
+			want:   []string{"2.0.0-beta.2", "2.0.0-beta.1"},
+			bodies: []string{"- Beta 2", "- Beta 1"},
+		},
+		{
+			name:     "sorted newest first",
+			markdown: "## 1.0.1\n\nA\n\n## 1.0.3\n\nC\n\n## 1.0.2\n\nB\n",
+			from:     "1.0.0", to: "1.0.3",
+			want:   []string{"1.0.3", "1.0.2", "1.0.1"},
+			bodies: []string{"C", "B", "A"},
+		},
+		{
+			name:     "CRLF line endings",
+			markdown: "## 1.1.0\r\n\r\n- Windows\r\n\r\n## 1.0.0\r\n",
+			from:     "1.0.0", to: "1.1.0",
+			want:   []string{"1.1.0"},
+			bodies: []string{"- Windows"},
+		},
+		{
+			name:     "no version headings",
+			markdown: "# Changelog\n\nSee the releases page.\n",
+			from:     "1.0.0", to: "1.1.0",
+		},
+		{
+			name:     "invalid bound",
+			markdown: "## 1.1.0\n\n- Shipped\n",
+			from:     "unknown", to: "1.1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := Parse(tt.markdown, tt.from, tt.to)
+			if got := versions(entries); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("versions = %v, want %v", got, tt.want)
+			}
+			for i, entry := range entries {
+				if entry.Body != tt.bodies[i] {
+					t.Errorf("body of %s = %q, want %q", entry.Version, entry.Body, tt.bodies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFromReleases(t *testing.T) {
+	releases := []github.Release{
+		{TagName: "v2.0.0-rc.1", Prerelease: true},
+		{TagName: "v1.3.0", Name: "v1.3.0", Body: "three"},
+		{TagName: "v1.2.0", Name: "Spring release", Body: "two"},
+		{TagName: "v1.1.0", Name: "1.1.0", Body: "one"},
+		{TagName: "v1.0.0", Body: "zero"},
+	}
+	monorepo := []github.Release{
+		{TagName: "@acme/cli@1.2.0", Body: "cli two"},
+		{TagName: "@acme/core@1.5.0", Body: "core"},
+		{TagName: "cli@1.1.0", Body: "cli one"},
+		{TagName: "docs-site", Body: "no version"},
+	}
+	tests := []struct {
+		name        string
+		releases    []github.Release
+		packageName string
+		from, to    string
+		want        []string
+		titles      []string
+	}{
+		{"range", releases, "tool", "1.0.0", "1.2.0", []string{"1.2.0", "1.1.0"}, []string{"Spring release", ""}},
+		{"prerelease skipped", releases, "tool", "1.2.0", "2.0.0", []string{"1.3.0"}, []string{""}},
+		{"prerelease kept up to a prerelease", releases, "tool", "1.2.0", "2.0.0-rc.1", []string{"2.0.0-rc.1", "1.3.0"}, []string{"", ""}},
+		{"monorepo tags of the package", monorepo, "@acme/cli", "1.0.0", "1.2.0", []string{"1.2.0", "1.1.0"}, []string{"", ""}},
+		{"monorepo tags of another package", monorepo, "@acme/core", "1.0.0", "1.2.0", nil, nil},
+		{"invalid bound", releases, "tool", "1.0.0", "latest", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := FromReleases(tt.releases, tt.packageName, tt.from, tt.to)
+			if got := versions(entries); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("versions = %v, want %v", got, tt.want)
+			}
+			for i, entry := range entries {
+				if entry.Title != tt.titles[i] {
+					t.Errorf("title of %s = %q, want %q", entry.Version, entry.Title, tt.titles[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReaches(t *testing.T) {
+	page := []github.Release{{TagName: "v1.3.0"}, {TagName: "v1.2.0"}, {TagName: "other@1.0.0"}}
+	tests := []struct {
+		name string
+		from string
+		want bool
+	}{
+		{"page holds from", "1.2.0", true},
+		{"page holds an older version", "1.2.5", true},
+		{"page only holds newer versions", "1.1.0", false},
+		{"other packages do not count", "1.0.0", false},
+		{"invalid version", "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := Reaches(page, "tool", tt.from); got != tt.want {
+			t.Errorf("%s: Reaches(%q) = %v, want %v", tt.name, tt.from, got, tt.want)
+		}
+	}
+}
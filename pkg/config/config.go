@@ -1,30 +1,467 @@
 package config
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/semver"
 )
 
 //go:embed tools.json
 var configFile []byte
 
+// ConfigEnv is the environment variable that points to an extra catalog file
+const ConfigEnv = "ATM_CONFIG"
+
+// Update policies accepted by Tool.Policy
+const (
+	// PolicyAuto updates the tool with the others (the default)
+	PolicyAuto = "auto"
+	// PolicyNotify reports updates but only applies them when the tool is named explicitly
+	PolicyNotify = "notify"
+	// PolicyPinned never updates the tool
+	PolicyPinned = "pinned"
+)
+
+// Package sources accepted by Tool.Source
+const (
+	// SourceNpm installs the package from the npm registry with the
+	// configured package manager (the default)
+	SourceNpm = "npm"
+	// SourcePipx installs a Python package from PyPI with pipx
+	SourcePipx = "pipx"
+	// SourceCargo installs a Rust crate from crates.io with cargo install
+	SourceCargo = "cargo"
+	// SourceGo installs a Go command with go install
+	SourceGo = "go"
+	// SourceGitHubRelease downloads a binary from the releases of a GitHub
+	// repository ("owner/repo")
+	SourceGitHubRelease = "github-release"
+)
+
+// SourceNames lists the accepted package sources
+var SourceNames = []string{SourceNpm, SourcePipx, SourceCargo, SourceGo, SourceGitHubRelease}
+
+// exactVersion matches the exact versions accepted for sources other than npm
+var exactVersion = regexp.MustCompile(`^v?[0-9][0-9A-Za-z.+_-]*$`)
+
+// distTag matches a registry dist-tag such as "latest" or "next"
+var distTag = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
 // Tool represents an AI development tool configuration
 type Tool struct {
 	Name        string `json:"name"`
 	Package     string `json:"package"`
 	Description string `json:"description"`
+
+	// Source is where the package comes from: npm, pipx, cargo, go or
+	// github-release. Empty means npm. Package is the npm package, the PyPI
+	// package, the crate, the Go package path or the "owner/repo" of the
+	// GitHub repository respectively.
+	Source string `json:"source,omitempty"`
+
+	// Version constrains installs and updates to an exact version ("1.2.3"),
+	// a range ("^1.2", "~1.2.3", ">=1 <2") or a dist-tag ("next").
+	// Sources other than npm only accept an exact version.
+	// Empty means the latest release.
+	Version string `json:"version,omitempty"`
+
+	// Policy is auto, notify or pinned. Empty means auto.
+	Policy string `json:"policy,omitempty"`
+
+	// Bin names the executables installed from a GitHub release. Empty
+	// means the one named after the repository.
+	Bin []string `json:"bin,omitempty"`
+}
+
+// UpdatePolicy returns the tool's update policy, defaulting to auto
+func (t Tool) UpdatePolicy() string {
+	if t.Policy == "" {
+		return PolicyAuto
+	}
+	return t.Policy
+}
+
+// SourceName returns where the tool's package comes from, defaulting to npm
+func (t Tool) SourceName() string {
+	if t.Source == "" {
+		return SourceNpm
+	}
+	return t.Source
+}
+
+// IsNpm reports whether the tool is installed from the npm registry
+func (t Tool) IsNpm() bool {
+	return t.SourceName() == SourceNpm
 }
 
 // Config represents the application configuration
 type Config struct {
 	Tools []Tool `json:"tools"`
+
+	// PackageManager selects the backend for global installs (npm, pnpm, yarn or bun).
+	// Empty means auto-detect.
+	PackageManager string `json:"packageManager"`
+
+	// Concurrency limits parallel registry requests. Zero means the default.
+	Concurrency int `json:"concurrency"`
+
+	// CacheTTL is how long cached latest versions are used before being
+	// refreshed, as a Go duration ("1h", "30m"). Empty means the default.
+	CacheTTL string `json:"cacheTTL"`
+
+	// Timeout bounds each install, update or uninstall, and RegistryTimeout
+	// each registry request, as Go durations ("10m", "30s"). "0" means no
+	// limit; empty means the default.
+	Timeout         string `json:"timeout"`
+	RegistryTimeout string `json:"registryTimeout"`
+
+	// UpdateCheckInterval is how long the result of a check for a new atm
+	// release is reused, as a Go duration ("24h"). "0" checks on every
+	// launch; empty means the default.
+	UpdateCheckInterval string `json:"updateCheckInterval"`
+
+	// GitHubAPIURL is the base URL of the GitHub API atm reads releases
+	// from, e.g. a GitHub Enterprise server or a mirror. Empty means api.github.com.
+	GitHubAPIURL string `json:"githubAPIURL"`
+
+	// Sources lists the catalog files that were merged, in load order
+	Sources []string `json:"-"`
+}
+
+// layer is a single catalog file. Tool fields are pointers so that a layer
+// can override only the fields it sets.
+type layer struct {
+	PackageManager      string `json:"packageManager"`
+	Concurrency         int    `json:"concurrency"`
+	CacheTTL            string `json:"cacheTTL"`
+	Timeout             string `json:"timeout"`
+	RegistryTimeout     string `json:"registryTimeout"`
+	UpdateCheckInterval string `json:"updateCheckInterval"`
+	GitHubAPIURL        string `json:"githubAPIURL"`
+	Tools               []struct {
+		Name        *string   `json:"name"`
+		Package     string    `json:"package"`
+		Description *string   `json:"description"`
+		Source      *string   `json:"source"`
+		Version     *string   `json:"version"`
+		Policy      *string   `json:"policy"`
+		Bin         *[]string `json:"bin"`
+		Hidden      *bool     `json:"hidden"`
+	} `json:"tools"`
 }
 
-// Load loads the configuration from the embedded JSON file
+// Load loads the embedded catalog and merges the user catalog files over it.
+// Layers are applied in order: embedded tools.json, <config dir>/tools.json,
+// then the file named by ATM_CONFIG. Entries are matched by package name;
+// a later layer can add a tool, override its fields or hide it.
 func Load() (*Config, error) {
-	var config Config
-	if err := json.Unmarshal(configFile, &config); err != nil {
+	config := &Config{}
+	hidden := make(map[string]bool)
+
+	if err := config.merge("tools.json (embedded)", configFile, hidden); err != nil {
 		return nil, err
 	}
-	return &config, nil
+
+	if dir, err := Dir(); err == nil {
+		if err := config.mergeFile(filepath.Join(dir, "tools.json"), false, hidden); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := os.Getenv(ConfigEnv); path != "" {
+		if err := config.mergeFile(path, true, hidden); err != nil {
+			return nil, err
+		}
+	}
+
+	tools := config.Tools[:0]
+	for _, tool := range config.Tools {
+		if !hidden[tool.Package] {
+			tools = append(tools, tool)
+		}
+	}
+	config.Tools = tools
+
+	return config, nil
+}
+
+// Dir returns the directory holding user configuration ($XDG_CONFIG_HOME/atm or ~/.config/atm)
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "atm"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "atm"), nil
+}
+
+// CacheDir returns the directory for cached data (the user cache dir followed by atm)
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "atm"), nil
+}
+
+// StateDir returns the directory for persistent state such as the update
+// history ($XDG_STATE_HOME/atm or ~/.local/state/atm)
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "atm"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "atm"), nil
+}
+
+// BinDir returns the directory binaries downloaded from GitHub releases are
+// installed into ($XDG_DATA_HOME/atm/bin or ~/.local/share/atm/bin)
+func BinDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "atm", "bin"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "atm", "bin"), nil
+}
+
+// mergeFile reads a catalog file and merges it. A missing file is only an
+// error when required is set.
+func (c *Config) mergeFile(path string, required bool, hidden map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return err
+	}
+	return c.merge(path, data, hidden)
+}
+
+// merge applies one catalog layer over the current configuration
+func (c *Config) merge(source string, data []byte, hidden map[string]bool) error {
+	var l layer
+	if err := json.Unmarshal(data, &l); err != nil {
+		return parseError(source, data, err)
+	}
+
+	if l.PackageManager != "" {
+		c.PackageManager = l.PackageManager
+	}
+	if l.Concurrency > 0 {
+		c.Concurrency = l.Concurrency
+	}
+	if l.CacheTTL != "" {
+		if _, err := time.ParseDuration(l.CacheTTL); err != nil {
+			return fmt.Errorf("%s: invalid cacheTTL: %w", source, err)
+		}
+		c.CacheTTL = l.CacheTTL
+	}
+	if l.Timeout != "" {
+		if _, err := time.ParseDuration(l.Timeout); err != nil {
+			return fmt.Errorf("%s: invalid timeout: %w", source, err)
+		}
+		c.Timeout = l.Timeout
+	}
+	if l.RegistryTimeout != "" {
+		if _, err := time.ParseDuration(l.RegistryTimeout); err != nil {
+			return fmt.Errorf("%s: invalid registryTimeout: %w", source, err)
+		}
+		c.RegistryTimeout = l.RegistryTimeout
+	}
+	if l.UpdateCheckInterval != "" {
+		if _, err := time.ParseDuration(l.UpdateCheckInterval); err != nil {
+			return fmt.Errorf("%s: invalid updateCheckInterval: %w", source, err)
+		}
+		c.UpdateCheckInterval = l.UpdateCheckInterval
+	}
+	if l.GitHubAPIURL != "" {
+		if err := ValidateAPIURL(l.GitHubAPIURL); err != nil {
+			return fmt.Errorf("%s: invalid githubAPIURL: %w", source, err)
+		}
+		c.GitHubAPIURL = l.GitHubAPIURL
+	}
+
+	for i, entry := range l.Tools {
+		if entry.Package == "" {
+			return fmt.Errorf("%s: tool #%d has no package", source, i+1)
+		}
+
+		if entry.Hidden != nil {
+			hidden[entry.Package] = *entry.Hidden
+		}
+
+		index := c.indexOf(entry.Package)
+		if index < 0 {
+			if entry.Name == nil {
+				if entry.Hidden != nil {
+					// Hiding a tool that no earlier layer defines
+					continue
+				}
+				return fmt.Errorf("%s: tool %q has no name", source, entry.Package)
+			}
+			c.Tools = append(c.Tools, Tool{Package: entry.Package})
+			index = len(c.Tools) - 1
+		}
+
+		tool := &c.Tools[index]
+		if entry.Name != nil {
+			tool.Name = *entry.Name
+		}
+		if entry.Description != nil {
+			tool.Description = *entry.Description
+		}
+		if entry.Source != nil {
+			if *entry.Source != "" && !slices.Contains(SourceNames, *entry.Source) {
+				return fmt.Errorf("%s: tool %q: invalid source %q (want one of: %s)", source, entry.Package, *entry.Source, strings.Join(SourceNames, ", "))
+			}
+			tool.Source = *entry.Source
+		}
+		if entry.Version != nil {
+			tool.Version = *entry.Version
+		}
+		if entry.Source != nil || entry.Version != nil {
+			if err := validateVersionSpec(tool.SourceName(), tool.Version); err != nil {
+				return fmt.Errorf("%s: tool %q: %w", source, entry.Package, err)
+			}
+		}
+		if entry.Policy != nil {
+			switch *entry.Policy {
+			case "", PolicyAuto, PolicyNotify, PolicyPinned:
+				tool.Policy = *entry.Policy
+			default:
+				return fmt.Errorf("%s: tool %q: invalid policy %q (want auto, notify or pinned)", source, entry.Package, *entry.Policy)
+			}
+		}
+		if entry.Bin != nil {
+			for _, name := range *entry.Bin {
+				if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+					return fmt.Errorf("%s: tool %q: invalid bin %q (want a file name)", source, entry.Package, name)
+				}
+			}
+			tool.Bin = *entry.Bin
+		}
+	}
+
+	c.Sources = append(c.Sources, source)
+	return nil
+}
+
+// validateVersionSpec checks that a version constraint is a range or a
+// dist-tag, or an exact version for sources other than npm
+func validateVersionSpec(packageSource, spec string) error {
+	if spec != "" && packageSource != SourceNpm {
+		if !exactVersion.MatchString(spec) {
+			return fmt.Errorf("version %q must be an exact version for source %s", spec, packageSource)
+		}
+		return nil
+	}
+	if spec == "" || distTag.MatchString(spec) {
+		return nil
+	}
+	if _, err := semver.ParseConstraint(spec); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ExactVersion reports whether version is an exact version of a package from
+// packageSource: a semantic version for npm, a version without operators for
+// the other sources
+func ExactVersion(packageSource, version string) bool {
+	if packageSource == "" || packageSource == SourceNpm {
+		_, err := semver.Parse(version)
+		return err == nil
+	}
+	return exactVersion.MatchString(version)
+}
+
+// ValidateAPIURL checks that an API base URL is an absolute http or https URL
+func ValidateAPIURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", rawURL)
+	}
+	return nil
+}
+
+// indexOf returns the index of the tool with the given package, or -1
+func (c *Config) indexOf(packageName string) int {
+	for i, tool := range c.Tools {
+		if tool.Package == packageName {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseError adds the file name, line and column to a JSON decoding error
+func parseError(source string, data []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return fmt.Errorf("%s:%d:%d: %w", source, line, column, err)
+}
+
+// FindTool looks up a tool by package name, display name or short name.
+// The short name is the last path segment of the package (e.g. "claude-code"
+// for "@anthropic-ai/claude-code") or the display name in kebab case.
+func (c *Config) FindTool(query string) (Tool, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return Tool{}, false
+	}
+
+	for _, tool := range c.Tools {
+		pkg := strings.ToLower(tool.Package)
+		name := strings.ToLower(tool.Name)
+
+		if query == pkg || query == name || query == pkg[strings.LastIndex(pkg, "/")+1:] ||
+			query == strings.ReplaceAll(name, " ", "-") {
+			return tool, true
+		}
+	}
+
+	return Tool{}, false
 }
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCatalogs points XDG_CONFIG_HOME and ATM_CONFIG at temporary catalog
+// files; an empty content leaves that file out
+func writeCatalogs(t *testing.T, user, extra string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv(ConfigEnv, "")
+	if user != "" {
+		if err := os.MkdirAll(filepath.Join(home, "atm"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(home, "atm", "tools.json"), []byte(user), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if extra != "" {
+		path := filepath.Join(t.TempDir(), "extra.json")
+		if err := os.WriteFile(path, []byte(extra), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(ConfigEnv, path)
+	}
+}
+
+// findPackage returns the tool with the given package
+func findPackage(c *Config, packageName string) (Tool, bool) {
+	if i := c.indexOf(packageName); i >= 0 {
+		return c.Tools[i], true
+	}
+	return Tool{}, false
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		extra string
+		check func(t *testing.T, c *Config)
+	}{
+		{
+			name: "embedded catalog only",
+			check: func(t *testing.T, c *Config) {
+				if len(c.Tools) == 0 || len(c.Sources) != 1 {
+					t.Errorf("loaded %d tools from %v, want the embedded catalog", len(c.Tools), c.Sources)
+				}
+			},
+		},
+		{
+			name: "user file overrides by package",
+			user: `{"tools": [{"package": "@openai/codex", "description": "team build"}]}`,
+			check: func(t *testing.T, c *Config) {
+				tool, ok := findPackage(c, "@openai/codex")
+				if !ok || tool.Description != "team build" || tool.Name == "" {
+					t.Errorf("codex = %+v, want the embedded name with the new description", tool)
+				}
+			},
+		},
+		{
+			name:  "ATM_CONFIG applies last",
+			user:  `{"concurrency": 2, "tools": [{"package": "@openai/codex", "description": "user"}]}`,
+			extra: `{"concurrency": 8, "tools": [{"package": "@openai/codex", "description": "extra"}]}`,
+			check: func(t *testing.T, c *Config) {
+				if tool, _ := findPackage(c, "@openai/codex"); tool.Description != "extra" {
+					t.Errorf("description = %q, want the ATM_CONFIG value", tool.Description)
+				}
+				if c.Concurrency != 8 || len(c.Sources) != 3 {
+					t.Errorf("concurrency = %d from %v, want 8 from three files", c.Concurrency, c.Sources)
+				}
+			},
+		},
+		{
+			name: "new tool",
+			user: `{"tools": [{"name": "Mine", "package": "my-cli", "source": "cargo", "version": "1.2.3"}]}`,
+			check: func(t *testing.T, c *Config) {
+				tool, ok := findPackage(c, "my-cli")
+				if !ok || tool.Name != "Mine" || tool.Source != SourceCargo || tool.Version != "1.2.3" {
+					t.Errorf("my-cli = %+v, %v", tool, ok)
+				}
+			},
+		},
+		{
+			name: "hidden tool",
+			user: `{"tools": [{"package": "@openai/codex", "hidden": true}, {"package": "not-in-catalog", "hidden": true}]}`,
+			check: func(t *testing.T, c *Config) {
+				if _, ok := findPackage(c, "@openai/codex"); ok {
+					t.Error("a hidden tool is still listed")
+				}
+			},
+		},
+		{
+			name:  "hidden tool shown again by a later layer",
+			user:  `{"tools": [{"package": "@openai/codex", "hidden": true}]}`,
+			extra: `{"tools": [{"package": "@openai/codex", "hidden": false}]}`,
+			check: func(t *testing.T, c *Config) {
+				if _, ok := findPackage(c, "@openai/codex"); !ok {
+					t.Error("the tool stayed hidden")
+				}
+			},
+		},
+		{
+			name: "settings",
+			user: `{"packageManager": "pnpm", "cacheTTL": "30m", "timeout": "0", "githubAPIURL": "https://ghe.example.com/api/v3/"}`,
+			check: func(t *testing.T, c *Config) {
+				if c.PackageManager != "pnpm" || c.CacheTTL != "30m" || c.Timeout != "0" || c.GitHubAPIURL != "https://ghe.example.com/api/v3/" {
+					t.Errorf("settings = %+v", c)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeCatalogs(t, tt.user, tt.extra)
+			c, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		want string
+	}{
+		{"parse error position", "{\n  \"tools\": [\n    {\"package\": \"x\",}\n  ]\n}", "tools.json:3:22"},
+		{"type error position", "{\n  \"concurrency\": \"many\"\n}", "tools.json:2:"},
+		{"tool without package", `{"tools": [{"name": "X"}]}`, "tool #1 has no package"},
+		{"new tool without name", `{"tools": [{"package": "x"}]}`, `tool "x" has no name`},
+		{"invalid source", `{"tools": [{"name": "X", "package": "x", "source": "apt"}]}`, `invalid source "apt"`},
+		{"invalid policy", `{"tools": [{"package": "@openai/codex", "policy": "never"}]}`, `invalid policy "never"`},
+		{"range for another source", `{"tools": [{"name": "X", "package": "x", "source": "pipx", "version": "^1.0"}]}`, "must be an exact version"},
+		{"invalid range", `{"tools": [{"package": "@openai/codex", "version": ">=<1"}]}`, `"@openai/codex"`},
+		{"invalid bin", `{"tools": [{"name": "X", "package": "a/x", "source": "github-release", "bin": ["../x"]}]}`, `invalid bin "../x"`},
+		{"invalid cacheTTL", `{"cacheTTL": "soon"}`, "invalid cacheTTL"},
+		{"invalid timeout", `{"timeout": "10"}`, "invalid timeout"},
+		{"invalid registryTimeout", `{"registryTimeout": "x"}`, "invalid registryTimeout"},
+		{"invalid updateCheckInterval", `{"updateCheckInterval": "daily"}`, "invalid updateCheckInterval"},
+		{"invalid githubAPIURL", `{"githubAPIURL": "ghe.example.com"}`, "invalid githubAPIURL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeCatalogs(t, tt.user, "")
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFiles(t *testing.T) {
+	writeCatalogs(t, "", "")
+	if _, err := Load(); err != nil {
+		t.Errorf("Load without a user file = %v, want nil", err)
+	}
+
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := Load(); err == nil {
+		t.Error("Load accepted a missing ATM_CONFIG file")
+	}
+}
+
+func TestFindTool(t *testing.T) {
+	c := &Config{Tools: []Tool{
+		{Name: "Claude Code", Package: "@anthropic-ai/claude-code"},
+		{Name: "Aider", Package: "aider-chat", Source: SourcePipx},
+	}}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"@anthropic-ai/claude-code", "@anthropic-ai/claude-code"},
+		{"claude-code", "@anthropic-ai/claude-code"},
+		{"  Claude Code ", "@anthropic-ai/claude-code"},
+		{"aider", "aider-chat"},
+		{"AIDER-CHAT", "aider-chat"},
+		{"claude", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		tool, ok := c.FindTool(tt.query)
+		if ok != (tt.want != "") || tool.Package != tt.want {
+			t.Errorf("FindTool(%q) = %q, %v; want %q", tt.query, tool.Package, ok, tt.want)
+		}
+	}
+}
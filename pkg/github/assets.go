@@ -0,0 +1,223 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrChecksumMismatch means a download does not match its published checksum
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ErrNoChecksum means a release publishes no checksum for an asset, so a
+// download of it cannot be verified
+var ErrNoChecksum = errors.New("the release publishes no checksum")
+
+// osAliases are the names release assets use for each operating system
+var osAliases = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "apple", "osx"},
+	"windows": {"windows", "win64", "win32"},
+}
+
+// archAliases are the names release assets use for each architecture
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64", "x86-64", "x64"},
+	"arm64": {"arm64", "aarch64"},
+	"386":   {"386", "i386", "i686"},
+	"arm":   {"armv7", "armv6", "armhf"},
+}
+
+// skippedAssets are suffixes of release assets that are not binaries
+var skippedAssets = []string{
+	".sha256", ".sha512", ".sha256sum", ".sig", ".asc", ".pem", ".sbom", ".json",
+	".txt", ".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg", ".appimage", ".tar.xz", ".vsix",
+}
+
+// checksumsFile matches the names of files listing the checksums of every
+// asset of a release, such as checksums.txt, tool_1.2.3_checksums.txt or SHA256SUMS
+var checksumsFile = regexp.MustCompile(`(?i)(^|[-_.])(checksums?|sha256sums?)(\.txt)?$`)
+
+// sha256Hex matches a hex-encoded SHA256 digest
+var sha256Hex = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// ChecksumError is a download whose SHA256 digest differs from the published one
+type ChecksumError struct {
+	Asset    string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s: SHA256 is %s, but the release publishes %s", e.Asset, e.Actual, e.Expected)
+}
+
+// Is makes errors.Is(err, ErrChecksumMismatch) match a ChecksumError
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// PickAsset chooses the release asset for an operating system and
+// architecture, preferring tar.gz archives, then zip archives, then tar.bz2
+// archives, then bare binaries
+func PickAsset(assets []Asset, goos, goarch string) (Asset, bool) {
+	best, bestScore := Asset{}, 0
+	for _, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if hasAnySuffix(name, skippedAssets) || checksumsFile.MatchString(name) || !containsAny(name, osAliases[goos]) {
+			continue
+		}
+		if !containsAny(name, archAliases[goarch]) && !(goos == "darwin" && strings.Contains(name, "universal")) {
+			continue
+		}
+
+		score := 1
+		switch {
+		case hasAnySuffix(name, []string{".tar.gz", ".tgz"}):
+			score = 4
+		case strings.HasSuffix(name, ".zip"):
+			score = 3
+		case hasAnySuffix(name, []string{".tar.bz2", ".tbz2"}):
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore = asset, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// Checksum returns the SHA256 checksum a release publishes for one of its
+// assets, read from an <asset>.sha256 file or from a checksums file listing
+// every asset. Releases with several checksums files, e.g. one per platform,
+// have each of them read until one lists the asset. ok is false when the
+// release publishes no checksums.
+func (c *Client) Checksum(ctx context.Context, release *Release, assetName string) (sum string, ok bool, err error) {
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		if asset, found := release.Asset(assetName + suffix); found {
+			data, err := c.downloadSmall(ctx, asset)
+			if err != nil {
+				return "", false, err
+			}
+			fields := strings.Fields(string(data))
+			if len(fields) == 0 || !sha256Hex.MatchString(fields[0]) {
+				return "", false, fmt.Errorf("%s is not a SHA256 checksum file", asset.Name)
+			}
+			return strings.ToLower(fields[0]), true, nil
+		}
+	}
+
+	var read []string
+	for _, asset := range release.Assets {
+		if !checksumsFile.MatchString(asset.Name) {
+			continue
+		}
+		data, err := c.downloadSmall(ctx, asset)
+		if err != nil {
+			return "", false, err
+		}
+		if sum, found := findChecksum(data, assetName); found {
+			return sum, true, nil
+		}
+		read = append(read, asset.Name)
+	}
+	if len(read) > 0 {
+		return "", false, fmt.Errorf("%w for %s in %s", ErrNoChecksum, assetName, strings.Join(read, ", "))
+	}
+	return "", false, nil
+}
+
+// downloadSmall downloads a small asset such as a checksums file into memory
+func (c *Client) downloadSmall(ctx context.Context, asset Asset) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.Download(ctx, asset, &limitedWriter{w: &buf, n: 1 << 20}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// findChecksum finds the SHA256 of a file in a checksums file, in the format
+// of sha256sum ("<sum>  <name>", "<sum> *<name>") or of BSD
+// ("SHA256 (<name>) = <sum>")
+func findChecksum(data []byte, name string) (string, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "SHA256 (") {
+			if file, sum, found := strings.Cut(strings.TrimPrefix(line, "SHA256 ("), ") = "); found && file == name && sha256Hex.MatchString(sum) {
+				return strings.ToLower(sum), true
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !sha256Hex.MatchString(fields[0]) {
+			continue
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		if file == name || strings.HasSuffix(file, "/"+name) {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+// VerifyFile checks that the SHA256 of a downloaded asset matches the
+// published checksum, returning a ChecksumError if it does not
+func VerifyFile(path, assetName, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return &ChecksumError{Asset: assetName, Expected: strings.ToLower(expected), Actual: actual}
+	}
+	return nil
+}
+
+// limitedWriter fails once more than n bytes are written
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, errors.New("checksum file is too large")
+	}
+	l.n -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnySuffix reports whether s ends with any of the suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sha256Of returns the hex-encoded SHA256 of data
+func sha256Of(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAssetServer serves files by name under /download/ and returns a release
+// whose assets point at them
+func newAssetServer(t *testing.T, files map[string]string) (*httptest.Server, *Release) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	t.Cleanup(server.Close)
+
+	release := &Release{TagName: "v1.0.0"}
+	for name := range files {
+		release.Assets = append(release.Assets, Asset{Name: name, URL: server.URL + "/download/" + name})
+	}
+	return server, release
+}
+
+func TestPickAsset(t *testing.T) {
+	assets := func(names ...string) []Asset {
+		var list []Asset
+		for _, name := range names {
+			list = append(list, Asset{Name: name})
+		}
+		return list
+	}
+	tests := []struct {
+		name   string
+		assets []Asset
+		goos   string
+		goarch string
+		want   string
+	}{
+		{"tar.gz over zip and binary", assets("tool-linux-amd64", "tool-linux-amd64.zip", "tool-linux-amd64.tar.gz"), "linux", "amd64", "tool-linux-amd64.tar.gz"},
+		{"zip over tar.bz2", assets("tool-linux-amd64.tar.bz2", "tool-linux-amd64.zip"), "linux", "amd64", "tool-linux-amd64.zip"},
+		{"architecture alias", assets("tool_Linux_x86_64.tar.gz", "tool_Linux_arm64.tar.gz"), "linux", "amd64", "tool_Linux_x86_64.tar.gz"},
+		{"operating system alias", assets("tool-aarch64-apple-darwin.tar.gz", "tool-aarch64-unknown-linux-gnu.tar.gz"), "darwin", "arm64", "tool-aarch64-apple-darwin.tar.gz"},
+		{"universal macOS binary", assets("tool-macos-universal.zip"), "darwin", "arm64", "tool-macos-universal.zip"},
+		{"checksums and packages skipped", assets("tool-linux-amd64.tar.gz.sha256", "tool_linux_amd64_checksums.txt", "tool-linux-amd64.deb"), "linux", "amd64", ""},
+		{"no asset for the platform", assets("tool-windows-amd64.zip"), "linux", "amd64", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, ok := PickAsset(tt.assets, tt.goos, tt.goarch)
+			if ok != (tt.want != "") || asset.Name != tt.want {
+				t.Errorf("PickAsset = %q, %v; want %q", asset.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	sum := sha256Of("binary")
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"sha256sum", sha256Of("other") + "  other.tar.gz\n" + sum + "  tool.tar.gz\n", sum},
+		{"binary mode", sum + " *tool.tar.gz\n", sum},
+		{"path prefix", sum + "  ./dist/tool.tar.gz\n", sum},
+		{"upper case", strings.ToUpper(sum) + "  tool.tar.gz\n", sum},
+		{"BSD", "SHA256 (tool.tar.gz) = " + sum + "\n", sum},
+		{"CRLF", sum + "  tool.tar.gz\r\n", sum},
+		{"not listed", sum + "  tool.zip\n", ""},
+		{"prefix of another name", sum + "  tool.tar.gz.sig\n", ""},
+		{"not a digest", "abc  tool.tar.gz\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findChecksum([]byte(tt.data), "tool.tar.gz")
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("findChecksum = %q, %v; want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	const asset = "tool-linux-amd64.tar.gz"
+	sum := sha256Of("binary")
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr error
+	}{
+		{"sidecar file", map[string]string{asset + ".sha256": sum + "  " + asset + "\n"}, sum, nil},
+		{"checksums file", map[string]string{"checksums.txt": sum + "  " + asset + "\n"}, sum, nil},
+		{"second checksums file", map[string]string{
+			"tool_darwin_checksums.txt": sha256Of("darwin") + "  tool-darwin-arm64.tar.gz\n",
+			"tool_linux_checksums.txt":  sum + "  " + asset + "\n",
+		}, sum, nil},
+		{"not listed anywhere", map[string]string{
+			"checksums.txt": sha256Of("other") + "  tool-darwin-arm64.tar.gz\n",
+			"SHA256SUMS":    sha256Of("other") + "  tool-windows-amd64.zip\n",
+		}, "", ErrNoChecksum},
+		{"no checksums", map[string]string{"README.md": "# tool"}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, release := newAssetServer(t, tt.files)
+			client := &Client{APIURL: server.URL, HTTPClient: server.Client()}
+
+			got, ok, err := client.Checksum(context.Background(), release, asset)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("Checksum = %q, %v; want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumRejectsMalformedSidecar(t *testing.T) {
+	server, release := newAssetServer(t, map[string]string{"tool.tar.gz.sha256": "not a checksum\n"})
+	client := &Client{APIURL: server.URL, HTTPClient: server.Client()}
+	if _, _, err := client.Checksum(context.Background(), release, "tool.tar.gz"); err == nil {
+		t.Error("Checksum accepted a sidecar file without a SHA256")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "download")
+	if err := os.WriteFile(path, []byte("binary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyFile(path, "tool.tar.gz", strings.ToUpper(sha256Of("binary"))); err != nil {
+		t.Errorf("VerifyFile with the right checksum = %v", err)
+	}
+
+	err := VerifyFile(path, "tool.tar.gz", sha256Of("tampered"))
+	var checksumErr *ChecksumError
+	if !errors.Is(err, ErrChecksumMismatch) || !errors.As(err, &checksumErr) || checksumErr.Actual != sha256Of("binary") {
+		t.Errorf("VerifyFile with another checksum = %v, want a ChecksumError", err)
+	}
+}
@@ -0,0 +1,326 @@
+// Package github reads releases and downloads release assets from the
+// GitHub REST API
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the public GitHub REST API
+const DefaultAPIURL = "https://api.github.com/"
+
+// DefaultTimeout bounds each API request of a client created without an HTTP client
+const DefaultTimeout = 10 * time.Second
+
+// ReleasesPerPage is the number of releases Releases reads per page, the
+// most the API allows
+const ReleasesPerPage = 100
+
+// maxFileSize bounds the files File reads
+const maxFileSize = 4 << 20
+
+// userAgent identifies atm; the GitHub API rejects requests without one
+const userAgent = "atm (https://github.com/xiaoxu123195/atm)"
+
+// ErrRateLimited means the API refused a request because the rate limit of
+// the client was exceeded
+var ErrRateLimited = errors.New("API rate limit exceeded")
+
+// Client reads releases from the GitHub API, or from a compatible API such
+// as GitHub Enterprise's or Gitee's. Point APIURL at a local server to use a
+// stand-in for GitHub.
+type Client struct {
+	// APIURL is the base URL of the REST API
+	APIURL string
+	// Token authenticates API requests, which raises the rate limit; it is
+	// not sent with downloads, which may be served by other hosts
+	Token string
+	// HTTPClient performs the requests; its Timeout bounds each API request
+	// but not downloads, which are bounded by their context
+	HTTPClient *http.Client
+}
+
+// Release is a published release of a repository
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// StatusError is an unsuccessful response from the API or a download
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Message    string
+	// RateLimited is set when the rate limit was exceeded, and Reset to when
+	// requests are accepted again, if the response said
+	RateLimited bool
+	Reset       time.Time
+}
+
+func (e *StatusError) Error() string {
+	if e.StatusCode == http.StatusNotFound {
+		return fmt.Sprintf("%s was not found", e.URL)
+	}
+	if e.RateLimited && !e.Reset.IsZero() {
+		return fmt.Sprintf("%s: API rate limit exceeded until %s", e.URL, e.Reset.Local().Format("15:04"))
+	}
+	if e.RateLimited {
+		return fmt.Sprintf("%s: API rate limit exceeded", e.URL)
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("%s returned %s: %s", e.URL, e.Status, e.Message)
+	}
+	return fmt.Sprintf("%s returned %s", e.URL, e.Status)
+}
+
+// Is makes errors.Is(err, ErrRateLimited) match a rate-limited response
+func (e *StatusError) Is(target error) bool {
+	return target == ErrRateLimited && e.RateLimited
+}
+
+// NewClient creates a client for the public API. A nil httpClient is
+// replaced by one with DefaultTimeout.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{APIURL: DefaultAPIURL, HTTPClient: httpClient}
+}
+
+// Version returns the version a release tag names, without a leading v
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Asset returns the asset with the given name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// LatestRelease reads the latest release of a repository ("owner/repo"),
+// which excludes drafts and prereleases
+func (c *Client) LatestRelease(ctx context.Context, repo string) (*Release, error) {
+	var release Release
+	if err := c.get(ctx, "repos/"+repo+"/releases/latest", &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ReleaseByTag reads the release of a tag
+func (c *Client) ReleaseByTag(ctx context.Context, repo, tag string) (*Release, error) {
+	var release Release
+	if err := c.get(ctx, "repos/"+repo+"/releases/tags/"+url.PathEscape(tag), &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// ReleaseByVersion reads the release of a version, trying the tag with a
+// leading v first, then the bare version
+func (c *Client) ReleaseByVersion(ctx context.Context, repo, version string) (*Release, error) {
+	version = strings.TrimPrefix(version, "v")
+	release, err := c.ReleaseByTag(ctx, repo, "v"+version)
+	if IsNotFound(err) {
+		release, err = c.ReleaseByTag(ctx, repo, version)
+	}
+	return release, err
+}
+
+// Releases reads a page of the published releases of a repository, newest
+// first. Pages hold ReleasesPerPage releases and are numbered from 1.
+func (c *Client) Releases(ctx context.Context, repo string, page int) ([]Release, error) {
+	var releases []Release
+	path := fmt.Sprintf("repos/%s/releases?per_page=%d&page=%d", repo, ReleasesPerPage, page)
+	if err := c.get(ctx, path, &releases); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// File reads a file from the default branch of a repository
+func (c *Client) File(ctx context.Context, repo, path string) ([]byte, error) {
+	resp, _, err := c.request(ctx, "repos/"+repo+"/contents/"+strings.TrimPrefix(path, "/"), "application/vnd.github.raw")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+}
+
+// Download writes the contents of a release asset to w
+func (c *Client) Download(ctx context.Context, asset Asset, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	req.Header.Set("User-Agent", userAgent)
+
+	// Downloads can take longer than a single API request may
+	client := *c.HTTPClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, asset.URL); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a 401 response, which means the token
+// was rejected
+func IsUnauthorized(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.StatusCode == http.StatusUnauthorized
+}
+
+// RateLimitReset returns when a rate-limited client may send requests again,
+// if err is a rate-limited response that said
+func RateLimitReset(err error) (time.Time, bool) {
+	var status *StatusError
+	if errors.As(err, &status) && status.RateLimited && !status.Reset.IsZero() {
+		return status.Reset, true
+	}
+	return time.Time{}, false
+}
+
+// get requests an API path and decodes its JSON response into v
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	resp, endpoint, err := c.request(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// request sends an authenticated API request and returns the successful
+// response, which the caller closes, and the URL it requested
+func (c *Client) request(ctx context.Context, path, accept string) (*http.Response, string, error) {
+	endpoint := strings.TrimSuffix(c.APIURL, "/") + "/" + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, endpoint, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", userAgent)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, endpoint, err
+	}
+	if err := checkStatus(resp, endpoint); err != nil {
+		resp.Body.Close()
+		return nil, endpoint, err
+	}
+	return resp, endpoint, nil
+}
+
+// checkStatus turns an unsuccessful response into a StatusError carrying
+// the message of the API's error document, if any
+func checkStatus(resp *http.Response, endpoint string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var document struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &document) == nil && document.Message != "" {
+		message = document.Message
+	}
+	if len(message) > 512 {
+		message = message[:512]
+	}
+
+	err := &StatusError{URL: endpoint, StatusCode: resp.StatusCode, Status: resp.Status, Message: message}
+	rateLimit(err, resp)
+	return err
+}
+
+// rateLimit marks a response refused for exceeding the primary rate limit
+// (X-RateLimit-Remaining: 0) or a secondary one (Retry-After) as rate
+// limited, with the time the limit resets
+func rateLimit(err *StatusError, resp *http.Response) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil {
+		err.RateLimited, err.Reset = true, time.Now().Add(time.Duration(seconds)*time.Second)
+		return
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		err.RateLimited = true
+		if reset, parseErr := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+			err.Reset = time.Unix(reset, 0)
+		}
+		return
+	}
+	err.RateLimited = resp.StatusCode == http.StatusTooManyRequests
+}
+
+// ParseRepository returns the "owner/repo" of a GitHub repository URL such
+// as https://github.com/owner/repo or git+https://github.com/owner/repo.git
+func ParseRepository(repositoryURL string) (string, bool) {
+	i := strings.Index(repositoryURL, "github.com")
+	if i < 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(repositoryURL[i+len("github.com"):], "/:")
+
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	repo := strings.TrimSuffix(parts[1], ".git")
+	return parts[0] + "/" + repo, true
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client whose API is handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &Client{APIURL: server.URL + "/", HTTPClient: server.Client()}
+}
+
+func TestStatusErrors(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name        string
+		status      int
+		headers     map[string]string
+		rateLimited bool
+		reset       time.Time
+	}{
+		{"primary rate limit", http.StatusForbidden, map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+		}, true, reset},
+		{"secondary rate limit", http.StatusForbidden, map[string]string{"Retry-After": "60"}, true, time.Time{}},
+		{"too many requests", http.StatusTooManyRequests, nil, true, time.Time{}},
+		{"forbidden", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "42"}, false, time.Time{}},
+		{"not found", http.StatusNotFound, nil, false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `{"message":"refused"}`)
+			})
+
+			_, err := client.LatestRelease(context.Background(), "acme/tool")
+			if got := errors.Is(err, ErrRateLimited); got != tt.rateLimited {
+				t.Fatalf("errors.Is(%v, ErrRateLimited) = %v, want %v", err, got, tt.rateLimited)
+			}
+			if got := IsNotFound(err); got != (tt.status == http.StatusNotFound) {
+				t.Errorf("IsNotFound(%v) = %v", err, got)
+			}
+			got, ok := RateLimitReset(err)
+			switch {
+			case !tt.reset.IsZero() && (!ok || !got.Equal(tt.reset)):
+				t.Errorf("RateLimitReset = %v, %v; want %v", got, ok, tt.reset)
+			case tt.headers["Retry-After"] != "" && (!ok || time.Until(got) <= 0):
+				t.Errorf("RateLimitReset = %v, %v; want a time after Retry-After", got, ok)
+			}
+			if !tt.rateLimited && tt.status == http.StatusForbidden {
+				var status *StatusError
+				if !errors.As(err, &status) || status.Message != "refused" {
+					t.Errorf("error = %v, want the message of the API", err)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseByVersion(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/tool/releases/tags/1.2.0" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"tag_name":"1.2.0","assets":[{"name":"tool.tar.gz","browser_download_url":"https://example.com/tool.tar.gz"}]}`)
+	})
+
+	release, err := client.ReleaseByVersion(context.Background(), "acme/tool", "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.Version() != "1.2.0" || len(release.Assets) != 1 {
+		t.Errorf("release = %+v, want the release tagged 1.2.0", release)
+	}
+
+	if _, err := client.ReleaseByVersion(context.Background(), "acme/tool", "9.9.9"); !IsNotFound(err) {
+		t.Errorf("error = %v, want a 404", err)
+	}
+}
+
+func TestTokenOnlySentToAPI(t *testing.T) {
+	var apiAuth, downloadAuth string
+	var client *Client
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/download" {
+			downloadAuth = r.Header.Get("Authorization")
+			fmt.Fprint(w, "binary")
+			return
+		}
+		apiAuth = r.Header.Get("Authorization")
+		fmt.Fprintf(w, `{"tag_name":"v1.0.0","assets":[{"name":"tool","browser_download_url":"%sdownload"}]}`, client.APIURL)
+	})
+	client.Token = "t0ken"
+
+	release, err := client.LatestRelease(context.Background(), "acme/tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Download(context.Background(), release.Assets[0], io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if apiAuth != "Bearer t0ken" {
+		t.Errorf("API request Authorization = %q, want the token", apiAuth)
+	}
+	if downloadAuth != "" {
+		t.Errorf("download Authorization = %q, want none", downloadAuth)
+	}
+}
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/acme/tool", "acme/tool"},
+		{"git+https://github.com/acme/tool.git", "acme/tool"},
+		{"git@github.com:acme/tool.git", "acme/tool"},
+		{"https://github.com/acme/tool/tree/main/packages/cli", "acme/tool"},
+		{"https://gitlab.com/acme/tool", ""},
+		{"https://github.com/acme", ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRepository(tt.url)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("ParseRepository(%q) = %q, %v; want %q", tt.url, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the history file in the state directory
+const FileName = "history.json"
+
+// fileVersion identifies the layout of the history file
+const fileVersion = 1
+
+// maxChanges is how many changes are kept per package
+const maxChanges = 10
+
+// lockTimeout is how long Record and Pop wait for another process to release
+// the history file; a lock older than staleLock was left behind by a process
+// that died and is removed
+const (
+	lockTimeout = 5 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+// ErrLocked means another atm process kept the history file locked for
+// longer than lockTimeout
+var ErrLocked = errors.New("the history file is locked by another atm process")
+
+// Change records that a package was moved from one version to another
+type Change struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// historyFile is the on-disk representation of a Store
+type historyFile struct {
+	Version int                 `json:"version"`
+	Tools   map[string][]Change `json:"tools"`
+}
+
+// Store keeps the recent version changes of each package in a JSON file, so
+// the last change can be rolled back. Every method reads the file again, and
+// Record and Pop hold a lock file while they change it, so concurrent atm
+// processes see each other's changes and never lose one.
+type Store struct {
+	path string
+}
+
+// NewStore creates a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Record appends a change for a package, dropping the oldest changes beyond the limit
+func (s *Store) Record(packageName, from, to string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	changes := append(file.Tools[packageName], Change{From: from, To: to, At: time.Now().UTC()})
+	if len(changes) > maxChanges {
+		changes = changes[len(changes)-maxChanges:]
+	}
+	file.Tools[packageName] = changes
+
+	return s.write(file)
+}
+
+// Last returns the most recent change of a package
+func (s *Store) Last(packageName string) (Change, bool, error) {
+	file, err := s.read()
+	if err != nil {
+		return Change{}, false, err
+	}
+
+	changes := file.Tools[packageName]
+	if len(changes) == 0 {
+		return Change{}, false, nil
+	}
+	return changes[len(changes)-1], true, nil
+}
+
+// Pop removes the most recent change of a package after it was rolled back
+func (s *Store) Pop(packageName string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	changes := file.Tools[packageName]
+	if len(changes) == 0 {
+		return nil
+	}
+	if len(changes) == 1 {
+		delete(file.Tools, packageName)
+	} else {
+		file.Tools[packageName] = changes[:len(changes)-1]
+	}
+
+	return s.write(file)
+}
+
+// lock creates the lock file next to the history file, waiting while another
+// process holds it, and returns the function that removes it. Creating a
+// file with O_EXCL is atomic on every platform atm supports.
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, err
+	}
+
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLocked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// read loads the history file. A missing file is an empty history.
+func (s *Store) read() (*historyFile, error) {
+	file := &historyFile{Version: fileVersion, Tools: make(map[string][]Change)}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return file, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Version != fileVersion || file.Tools == nil {
+		// Written by an incompatible release; start over
+		file.Version = fileVersion
+		file.Tools = make(map[string][]Change)
+	}
+	return file, nil
+}
+
+// write replaces the history file atomically
+func (s *Store) write(file *historyFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
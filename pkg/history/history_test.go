@@ -0,0 +1,90 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentRecord records changes from many stores sharing one file, as
+// concurrent atm processes do, and checks that none is lost
+func TestConcurrentRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", FileName)
+	const workers, changes = 8, 5
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			store := NewStore(path)
+			for i := 0; i < changes; i++ {
+				if err := store.Record(fmt.Sprintf("pkg-%d", worker), fmt.Sprintf("1.%d.0", i), fmt.Sprintf("1.%d.0", i+1)); err != nil {
+					t.Error(err)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	file, err := NewStore(path).read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for worker := 0; worker < workers; worker++ {
+		if got := len(file.Tools[fmt.Sprintf("pkg-%d", worker)]); got != changes {
+			t.Errorf("pkg-%d has %d changes, want %d", worker, got, changes)
+		}
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("the lock file was left behind: %v", err)
+	}
+}
+
+func TestRecordLastPop(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+
+	if _, ok, err := store.Last("pkg"); err != nil || ok {
+		t.Fatalf("Last on an empty history = %v, %v; want nothing", ok, err)
+	}
+	for i := 0; i < maxChanges+2; i++ {
+		if err := store.Record("pkg", fmt.Sprintf("1.%d.0", i), fmt.Sprintf("1.%d.0", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, ok, err := store.Last("pkg")
+	if err != nil || !ok || last.From != "1.11.0" || last.To != "1.12.0" {
+		t.Fatalf("Last = %+v, %v, %v; want 1.11.0 → 1.12.0", last, ok, err)
+	}
+	file, _ := store.read()
+	if got := len(file.Tools["pkg"]); got != maxChanges {
+		t.Errorf("kept %d changes, want %d", got, maxChanges)
+	}
+
+	if err := store.Pop("pkg"); err != nil {
+		t.Fatal(err)
+	}
+	if last, _, _ := store.Last("pkg"); last.To != "1.11.0" {
+		t.Errorf("Last after Pop = %+v, want the change to 1.11.0", last)
+	}
+}
+
+func TestStaleLockIsRemoved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewStore(path).Record("pkg", "1.0.0", "1.1.0"); err != nil {
+		t.Errorf("Record with a stale lock = %v, want it removed", err)
+	}
+}
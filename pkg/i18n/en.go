@@ -13,12 +13,16 @@ var enMessages = map[string]string{
 	"menu.install":   "Install Tools",
 	"menu.query":     "Query Tools",
 	"menu.update":    "Update Tools",
+	"menu.version":   "Choose Version",
+	"menu.rollback":  "Roll Back Tools",
 	"menu.uninstall": "Uninstall Tools",
 	"menu.exit":      "Exit",
 
 	// Prompts
 	"prompts.useArrowKeys": "(Use arrow keys)",
-	"prompts.pressSpace":   "(Press space to select, enter to confirm)",
+	"prompts.pressSpace":   "(Space to select, a to select all, / to filter, enter to confirm)",
+	"prompts.filter":       "(Type to filter, enter to keep the filter, esc to clear it)",
+	"prompts.noMatches":    "No matching tools",
 	"prompts.confirm":      "(y/N)",
 
 	// Install
@@ -28,6 +32,7 @@ var enMessages = map[string]string{
 	"install.installing":      "Installing %s...",
 	"install.success":         "Successfully installed %s",
 	"install.failed":          "Failed to install %s: %s",
+	"install.versionMismatch": "installed version is \"%s\" after the install",
 
 	// Query
 	"query.noneInstalled":    "No tools installed",
@@ -37,15 +42,30 @@ var enMessages = map[string]string{
 	"query.updateAvailable":  "(Update available: v%s)",
 	"query.upToDate":         "(Up to date)",
 	"query.unknownVersion":   "Unknown",
+	"query.pinned":           "(Pinned)",
 
 	// Update
-	"update.checking":      "Checking for updates...",
-	"update.allUpToDate":   "All tools are up to date",
-	"update.selectToUpdate": "Select tools to update:",
-	"update.noneSelected":  "No tools selected",
-	"update.updating":      "Updating %s...",
-	"update.success":       "Successfully updated %s",
-	"update.failed":        "Failed to update %s: %s",
+	"update.checking":         "Checking for updates...",
+	"update.allUpToDate":      "All tools are up to date",
+	"update.selectToUpdate":   "Select tools to update:",
+	"update.noneSelected":     "No tools selected",
+	"update.updating":         "Updating %s...",
+	"update.success":          "Successfully updated %s",
+	"update.failed":           "Failed to update %s: %s",
+	"update.confirm":          "Update %d tool(s)?",
+	"update.updateNow":        "Update now",
+	"update.showNotes":        "Show release notes first",
+	"update.cancel":           "Cancel",
+	"update.cancelled":        "Update cancelled",
+	"update.fetchingNotes":    "Fetching release notes...",
+	"update.notesHeader":      "%s  v%s → v%s",
+	"update.noNotes":          "No release notes found",
+	"update.emptyNotes":       "(No notes)",
+	"update.moreLines":        "… %d more lines",
+	"update.moreReleases":     "… %d older releases",
+	"update.notesAt":          "More at %s",
+	"update.notesFailed":      "Could not read the release notes: %s",
+	"update.notesRateLimited": "the GitHub API rate limit was reached; set GITHUB_TOKEN to raise it",
 
 	// Uninstall
 	"uninstall.noneInstalled":      "No tools installed",
@@ -58,15 +78,244 @@ var enMessages = map[string]string{
 	"uninstall.failed":             "Failed to uninstall %s: %s",
 
 	// Version
-	"version.checking":          "Checking for updates...",
-	"version.updateAvailable":   "A new version of ATM is available!",
-	"version.currentVersion":    "Current version: v%s",
-	"version.latestVersion":     "Latest version: v%s",
-	"version.updatePrompt":      "Would you like to open the repository?",
-	"version.openRepository":    "Yes, open repository",
-	"version.skipUpdate":        "No, skip for now",
-	"version.repositoryOpened":  "Repository opened in browser",
+	"version.checking":             "Checking for updates...",
+	"version.updateAvailable":      "A new version of ATM is available!",
+	"version.currentVersion":       "Current version: v%s",
+	"version.latestVersion":        "Latest version: v%s",
+	"version.updatePrompt":         "How would you like to update?",
+	"version.selfUpdate":           "Yes, update atm now",
+	"version.openRepository":       "Yes, open repository",
+	"version.skipUpdate":           "No, skip for now",
+	"version.repositoryOpened":     "Repository opened in browser",
 	"version.repositoryOpenFailed": "Could not open browser automatically. Please visit: %s",
+	"version.restart":              "Restart atm to use the new version",
+	"version.rateLimited":          "The GitHub API rate limit was reached; the update check was skipped",
+	"version.rateLimitedUntil":     "The GitHub API rate limit was reached until %s; the update check was skipped",
+	"version.tokenRejected":        "GitHub rejected GITHUB_TOKEN; the update check was skipped",
+	"version.hint.rateLimited":     "Set GITHUB_TOKEN to a GitHub token to raise the limit",
+	"version.hint.tokenRejected":   "Check that GITHUB_TOKEN is a valid token that has not expired, or unset it",
+
+	// Self-update
+	"selfUpdate.checking":        "Checking the releases of atm...",
+	"selfUpdate.upToDate":        "atm v%s is already the latest version",
+	"selfUpdate.dryRun":          "Would replace %s (v%s) with v%s",
+	"selfUpdate.asset":           "Asset %s from %s",
+	"selfUpdate.downloading":     "Downloading atm v%s...",
+	"selfUpdate.success":         "Updated atm from v%s to v%s",
+	"selfUpdate.backup":          "The previous executable is kept at %s; restore it with atm self-update --rollback",
+	"selfUpdate.rolledBack":      "Restored the previous executable at %s",
+	"selfUpdate.rollbackDryRun":  "Would restore %s from %s",
+	"selfUpdate.failed":          "Failed to update atm: %s",
+	"selfUpdate.notFound":        "the release does not exist",
+	"selfUpdate.noChecksum":      "the release publishes no checksum for the atm binary, so it cannot be verified",
+	"selfUpdate.noBackup":        "no previous executable was kept; there is nothing to roll back to",
+	"selfUpdate.hint.notFound":   "Check the version against the releases of the repository",
+	"selfUpdate.hint.noChecksum": "Download the binary from the release page yourself, or pick a release with checksums using --version",
+	"selfUpdate.hint.permission": "atm cannot write to the directory it is installed in; rerun with the permissions used to install it, or update it the way you installed it",
+
+	// CLI
+	"cli.usage":              "Usage: atm [--refresh] [command] [options]",
+	"cli.usage.prefix":       "Usage:",
+	"cli.usage.install":      "atm install <tool>[@<version>]...       Install tools, optionally at a version",
+	"cli.usage.update":       "atm update <tool>... | --all            Update tools to the latest version",
+	"cli.usage.uninstall":    "atm uninstall <tool>...                 Uninstall tools",
+	"cli.usage.versions":     "atm versions <tool> [--all]             List published versions of a tool",
+	"cli.usage.rollback":     "atm rollback <tool>...                  Restore the version installed before the last update",
+	"cli.usage.history":      "atm history [--tool <tool>] [options]   Show the operation log",
+	"cli.usage.list":         "atm list [-o json|yaml]                 List all tools and their status",
+	"cli.usage.outdated":     "atm outdated [-o json|yaml]             List tools with available updates",
+	"cli.usage.lock":         "atm lock [--file <path>]                Write installed versions to a lockfile",
+	"cli.usage.sync":         "atm sync [--check] [--file <path>]      Install the versions in a lockfile",
+	"cli.usage.doctor":       "atm doctor                              Check the environment for common problems",
+	"cli.usage.dashboard":    "atm dashboard                           Open the full-screen dashboard",
+	"cli.usage.selfUpdate":   "atm self-update [options]               Update atm itself to the latest release",
+	"cli.usage.interactive":  "Run atm without arguments to start the interactive menu.",
+	"cli.unknownCommand":     "Unknown command: %s",
+	"cli.invalidOutput":      "Unsupported output format: %s (expected text, json or yaml)",
+	"cli.unknownTool":        "Unknown tool: %s",
+	"cli.alreadyInstalled":   "%s is already installed",
+	"cli.notInstalled":       "%s is not installed",
+	"cli.notInstalledStatus": "not installed",
+	"cli.upToDate":           "%s is up to date (v%s)",
+	"cli.latestUnknown":      "Could not determine the latest version of %s",
+	"cli.pinned":             "%s is pinned; skipping",
+	"cli.notifySkipped":      "Skipping %s (v%s available): its update policy is notify",
+
+	// Lock
+	"lock.resolving":            "Resolving integrity hashes...",
+	"lock.written":              "Wrote %s with %d tool(s)",
+	"lock.integrityUnavailable": "Could not read the integrity hash of %s v%s from the registry",
+	"sync.readError":            "Failed to read lockfile: %s",
+	"sync.inSync":               "All tools match %s",
+	"sync.drift":                "%d tool(s) differ from %s:",
+	"sync.driftMissing":         "%s: not installed (locked v%s)",
+	"sync.driftVersion":         "%s: installed v%s, locked v%s",
+	"sync.installing":           "Installing %s v%s...",
+	"sync.success":              "%s is now at v%s",
+	"sync.failed":               "Failed to sync %s: %s",
+	"sync.versionNotFound":      "version %s is not published in the registry",
+	"sync.integrityMismatch":    "integrity hash of version %s does not match the lockfile",
+	"sync.unknownSource":        "the lockfile names an unknown source %q",
+	"sync.sourceMismatch":       "it is locked from %s, but the catalog installs it from %s",
+
+	// Versions
+	"versions.selectTool":       "Select a tool:",
+	"versions.fetching":         "Fetching versions of %s...",
+	"versions.fetchFailed":      "Failed to read the versions of %s: %s",
+	"versions.none":             "No published versions of %s found",
+	"versions.select":           "Select a version of %s:",
+	"versions.tagInstalled":     "installed",
+	"versions.tagDeprecated":    "deprecated",
+	"versions.more":             "%d older version(s) not shown; use --all to list them",
+	"versions.resolving":        "Resolving %s@%s...",
+	"versions.alreadyAt":        "%s is already at v%s",
+	"versions.confirmDowngrade": "Downgrade %s from v%s to v%s?",
+	"versions.installing":       "Installing %s v%s...",
+	"versions.upgrading":        "Upgrading %s from v%s to v%s...",
+	"versions.downgrading":      "Downgrading %s from v%s to v%s...",
+	"versions.installed":        "Installed %s v%s",
+	"versions.upgraded":         "Upgraded %s to v%s",
+	"versions.downgraded":       "Downgraded %s to v%s",
+	"versions.pinHint":          "To keep this version, set \"policy\": \"pinned\" for %s in the catalog",
+	"versions.npmOnly":          "version lists are only available for npm packages, not for source %s",
+
+	// Rollback
+	"rollback.select":        "Select a tool to roll back:",
+	"rollback.noneAvailable": "No updates to roll back",
+	"rollback.noHistory":     "No previous version of %s is recorded",
+	"rollback.rollingBack":   "Rolling back %s to v%s...",
+	"rollback.success":       "Rolled back %s from v%s to v%s",
+	"rollback.failed":        "Failed to roll back %s: %s",
+	"rollback.recordFailed":  "Could not record the previous version of %s: %s",
+	"rollback.historyError":  "Failed to read the update history: %s",
+
+	// History
+	"history.empty":       "No operations recorded",
+	"history.invalidDate": "Invalid date: %s (expected YYYY-MM-DD or RFC 3339)",
+	"history.readError":   "Failed to read the operation log: %s",
+	"history.writeError":  "Could not write the operation log: %s",
+
+	// Doctor
+	"doctor.summary":               "%d passed, %d warnings, %d failed",
+	"doctor.label.prefix":          "Global directory",
+	"doctor.label.bin":             "Global bin directory",
+	"doctor.label.releaseBin":      "Release bin directory",
+	"doctor.label.registry":        "Registry",
+	"doctor.label.proxy":           "Proxy",
+	"doctor.notFound":              "not found on PATH",
+	"doctor.versionFailed":         "found at %s, but it failed to report its version: %s",
+	"doctor.nodeTooOld":            "%s is older than the supported v%d",
+	"doctor.lookupFailed":          "could not be determined: %s",
+	"doctor.prefixWritable":        "%s is writable",
+	"doctor.prefixReadOnly":        "%s is not writable by the current user",
+	"doctor.onPath":                "%s is on PATH",
+	"doctor.notOnPath":             "%s is not on PATH",
+	"doctor.registryReachable":     "%s is reachable",
+	"doctor.registryStatus":        "%s answered HTTP %d",
+	"doctor.registryUnreachable":   "%s is unreachable: %s",
+	"doctor.proxyNone":             "no proxy configured",
+	"doctor.proxyNpmOnly":          "npm uses %s, but HTTPS_PROXY is not set",
+	"doctor.proxyInvalid":          "%s is not a valid proxy URL",
+	"doctor.manifestError":         "failed to read the package manifest: %s",
+	"doctor.binMissing":            "not found on PATH",
+	"doctor.binShadowed":           "resolves to %s instead of the copy in %s",
+	"doctor.hint.installNode":      "Install Node.js %d or later from https://nodejs.org",
+	"doctor.hint.upgradeNode":      "Upgrade Node.js to v%d or later",
+	"doctor.hint.installNpm":       "npm ships with Node.js; reinstall Node.js from https://nodejs.org",
+	"doctor.hint.installBackend":   "Install %s, or choose another package manager with ATM_PACKAGE_MANAGER",
+	"doctor.hint.reinstallProgram": "Reinstall %s",
+	"doctor.hint.lookup":           "Check that the package manager runs correctly from this shell",
+	"doctor.hint.prefix":           "Do not use sudo; point the global directory at a folder you own, e.g. npm config set prefix ~/.npm-global",
+	"doctor.hint.addToPath":        "Add %s to PATH in your shell profile, then open a new terminal",
+	"doctor.hint.network":          "Check your network connection, the registry URL in .npmrc and the proxy settings",
+	"doctor.hint.auth":             "Log in with npm login, or check the registry token in .npmrc",
+	"doctor.hint.registryError":    "The registry has a problem; try again later or use another registry",
+	"doctor.hint.proxyEnv":         "atm only uses the HTTPS_PROXY and HTTP_PROXY variables; set them to the same proxy",
+	"doctor.hint.proxyInvalid":     "Use a URL such as http://proxy.example.com:8080",
+	"doctor.hint.reinstall":        "Reinstall it with atm uninstall %[1]s and atm install %[1]s",
+	"doctor.hint.binMissing":       "Make sure the global bin directory is on PATH, or reinstall %s",
+	"doctor.hint.binShadowed":      "Remove the other copy, or move %s earlier in PATH",
+	"doctor.hint.installSource":    "Install %s to manage the tools with source %s",
+
+	// Prefix
+	"prefix.denied":       "The package manager is not allowed to write to its global directory (EACCES). atm does not retry with sudo.",
+	"prefix.otherBackend": "Point the global directory of %s at a folder you own, then try again",
+	"prefix.explain":      "npm can install global packages in %s, a directory you own, so no administrator rights are needed. Tools already installed in the old directory stay there.",
+	"prefix.confirm":      "Set npm's global prefix to %s, add %s to PATH and try again?",
+	"prefix.manual":       "To fix this yourself, run npm config set prefix %s and add %s to PATH",
+	"prefix.configured":   "npm now installs global packages in %s",
+	"prefix.pathAdded":    "Added %s to PATH in %s; open a new terminal to use it",
+	"prefix.pathManual":   "Add %s to your user PATH in the system settings",
+	"prefix.pathFailed":   "Could not update the shell profile: %s. Add %s to PATH yourself",
+	"prefix.failed":       "Failed to configure the npm prefix: %s",
+
+	// Errors
+	"errors.hint":                "Hint: %s",
+	"errors.notFound":            "the package or version does not exist in the registry",
+	"errors.permission":          "no permission to write to the global package directory",
+	"errors.network":             "the registry could not be reached",
+	"errors.engine":              "the package does not support the installed Node.js version",
+	"errors.npmMissing":          "%s is not installed or not on PATH",
+	"errors.timeout":             "the operation took too long and was stopped",
+	"errors.interrupted":         "interrupted by the user",
+	"errors.checksum":            "the download does not match the checksum the release publishes",
+	"errors.noChecksum":          "the release publishes no checksum for the download, so it cannot be verified",
+	"errors.hint.notFound":       "Check the package name and version, and the registry configured in .npmrc",
+	"errors.hint.permission":     "Do not use sudo; run atm in a terminal to set up a user-owned npm prefix, or run atm doctor",
+	"errors.hint.network":        "Check your network connection and proxy settings (HTTPS_PROXY, npm's https-proxy), or run atm doctor",
+	"errors.hint.engine":         "Upgrade Node.js, or install an older version with atm install <tool>@<version>",
+	"errors.hint.npmMissing":     "Install the package manager (npm comes with Node.js from https://nodejs.org), or choose another one with ATM_PACKAGE_MANAGER",
+	"errors.hint.timeout":        "Raise the limit with ATM_TIMEOUT for package operations or ATM_REGISTRY_TIMEOUT for registry requests, e.g. ATM_TIMEOUT=30m",
+	"errors.hint.commandMissing": "Install %s and make sure it is on PATH",
+	"errors.hint.checksum":       "The download may be corrupt or tampered with; try again, and report it to the project if it persists",
+	"errors.hint.noChecksum":     "Download the binary from the release page yourself, or ask the project to publish checksums",
+
+	// Interrupt
+	"interrupt.interrupted":    "Interrupted: %s",
+	"interrupt.notInstalled":   "%s is not installed",
+	"interrupt.unchanged":      "%s is still at %s",
+	"interrupt.installed":      "%s %s was installed",
+	"interrupt.removed":        "%s %s was removed",
+	"interrupt.changed":        "%s is now at %s (was %s)",
+	"interrupt.hint":           "Run the command again to finish, or atm doctor if the tool does not work",
+	"interrupt.nothingChanged": "nothing was changed",
+
+	// Dashboard
+	"dashboard.title":              "ATM %s · %s",
+	"dashboard.summary":            "%d installed · updates for %d",
+	"dashboard.colTool":            "Tool",
+	"dashboard.colPackage":         "Package",
+	"dashboard.colStatus":          "Status",
+	"dashboard.colCurrent":         "Installed",
+	"dashboard.colLatest":          "Latest",
+	"dashboard.installed":          "installed",
+	"dashboard.notInstalled":       "not installed",
+	"dashboard.updateBadge":        "update available",
+	"dashboard.pinnedBadge":        "pinned",
+	"dashboard.queuedBadge":        "queued",
+	"dashboard.operations":         "Operations",
+	"dashboard.help":               "↑/↓ move  i install  u update  U update all  d uninstall  enter details  r refresh  c clear  q quit",
+	"dashboard.detailsHelp":        "esc back  i install  u update  d uninstall  q quit",
+	"dashboard.confirmUninstall":   "Uninstall %s? (y/N)",
+	"dashboard.queued":             "%s already has an operation queued",
+	"dashboard.busy":               "An operation is running; wait for it to finish or press Ctrl+C to cancel",
+	"dashboard.cancelling":         "Cancelling; press Ctrl+C again to leave at once",
+	"dashboard.cancelled":          "cancelled",
+	"dashboard.notTerminal":        "The dashboard needs an interactive terminal",
+	"dashboard.job.check":          "Check latest versions",
+	"dashboard.job.refresh":        "Refresh installed tools and versions",
+	"dashboard.job.install":        "Install %s",
+	"dashboard.job.update":         "Update %s",
+	"dashboard.job.uninstall":      "Uninstall %s",
+	"dashboard.detail.package":     "Package",
+	"dashboard.detail.source":      "Source",
+	"dashboard.detail.description": "Description",
+	"dashboard.detail.status":      "Status",
+	"dashboard.detail.current":     "Installed version",
+	"dashboard.detail.latest":      "Latest version",
+	"dashboard.detail.constraint":  "Version constraint",
+	"dashboard.detail.policy":      "Update policy",
+	"dashboard.detail.bins":        "Executables",
 
 	// Config
 	"config.loadError": "Failed to load configuration",
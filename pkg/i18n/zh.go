@@ -13,12 +13,16 @@ var zhMessages = map[string]string{
 	"menu.install":   "安装工具",
 	"menu.query":     "查询工具",
 	"menu.update":    "更新工具",
+	"menu.version":   "选择版本",
+	"menu.rollback":  "回滚工具",
 	"menu.uninstall": "卸载工具",
 	"menu.exit":      "退出",
 
 	// Prompts
 	"prompts.useArrowKeys": "(使用方向键选择)",
-	"prompts.pressSpace":   "(空格键选择，回车键确认)",
+	"prompts.pressSpace":   "(空格键选择，a 全选，/ 筛选，回车键确认)",
+	"prompts.filter":       "(输入以筛选，回车键保留筛选，esc 清除筛选)",
+	"prompts.noMatches":    "没有匹配的工具",
 	"prompts.confirm":      "(y/N)",
 
 	// Install
@@ -28,6 +32,7 @@ var zhMessages = map[string]string{
 	"install.installing":      "正在安装 %s...",
 	"install.success":         "成功安装 %s",
 	"install.failed":          "安装 %s 失败：%s",
+	"install.versionMismatch": "安装后的版本为 \"%s\"",
 
 	// Query
 	"query.noneInstalled":   "未安装任何工具",
@@ -37,15 +42,30 @@ var zhMessages = map[string]string{
 	"query.updateAvailable": "(可更新至：v%s)",
 	"query.upToDate":        "(已是最新)",
 	"query.unknownVersion":  "未知",
+	"query.pinned":          "(已固定)",
 
 	// Update
-	"update.checking":       "正在检查更新...",
-	"update.allUpToDate":    "所有工具已是最新版本",
-	"update.selectToUpdate": "选择要更新的工具：",
-	"update.noneSelected":   "未选择任何工具",
-	"update.updating":       "正在更新 %s...",
-	"update.success":        "成功更新 %s",
-	"update.failed":         "更新 %s 失败：%s",
+	"update.checking":         "正在检查更新...",
+	"update.allUpToDate":      "所有工具已是最新版本",
+	"update.selectToUpdate":   "选择要更新的工具：",
+	"update.noneSelected":     "未选择任何工具",
+	"update.updating":         "正在更新 %s...",
+	"update.success":          "成功更新 %s",
+	"update.failed":           "更新 %s 失败：%s",
+	"update.confirm":          "更新 %d 个工具？",
+	"update.updateNow":        "立即更新",
+	"update.showNotes":        "先查看更新日志",
+	"update.cancel":           "取消",
+	"update.cancelled":        "已取消更新",
+	"update.fetchingNotes":    "正在获取更新日志...",
+	"update.notesHeader":      "%s  v%s → v%s",
+	"update.noNotes":          "未找到更新日志",
+	"update.emptyNotes":       "（无说明）",
+	"update.moreLines":        "… 还有 %d 行",
+	"update.moreReleases":     "… 还有 %d 个更早的版本",
+	"update.notesAt":          "详见 %s",
+	"update.notesFailed":      "无法读取更新日志：%s",
+	"update.notesRateLimited": "已达到 GitHub API 速率限制；设置 GITHUB_TOKEN 可提高限额",
 
 	// Uninstall
 	"uninstall.noneInstalled":     "未安装任何工具",
@@ -62,11 +82,240 @@ var zhMessages = map[string]string{
 	"version.updateAvailable":      "ATM 有新版本可用！",
 	"version.currentVersion":       "当前版本：v%s",
 	"version.latestVersion":        "最新版本：v%s",
-	"version.updatePrompt":         "是否打开仓库？",
+	"version.updatePrompt":         "您希望如何更新？",
+	"version.selfUpdate":           "是，立即更新 atm",
 	"version.openRepository":       "是，打开仓库",
 	"version.skipUpdate":           "否，暂时跳过",
 	"version.repositoryOpened":     "已在浏览器中打开仓库",
 	"version.repositoryOpenFailed": "无法自动打开浏览器，请访问：%s",
+	"version.restart":              "重新启动 atm 以使用新版本",
+	"version.rateLimited":          "已达到 GitHub API 速率限制，已跳过更新检查",
+	"version.rateLimitedUntil":     "已达到 GitHub API 速率限制（至 %s），已跳过更新检查",
+	"version.tokenRejected":        "GitHub 拒绝了 GITHUB_TOKEN，已跳过更新检查",
+	"version.hint.rateLimited":     "将 GITHUB_TOKEN 设置为 GitHub 令牌以提高限额",
+	"version.hint.tokenRejected":   "检查 GITHUB_TOKEN 是否为未过期的有效令牌，或取消设置",
+
+	// Self-update
+	"selfUpdate.checking":        "正在检查 atm 的发布...",
+	"selfUpdate.upToDate":        "atm v%s 已是最新版本",
+	"selfUpdate.dryRun":          "将用 v%[3]s 替换 %[1]s（v%[2]s）",
+	"selfUpdate.asset":           "附件 %s，来自 %s",
+	"selfUpdate.downloading":     "正在下载 atm v%s...",
+	"selfUpdate.success":         "已将 atm 从 v%s 更新到 v%s",
+	"selfUpdate.backup":          "旧的可执行文件保存在 %s；可通过 atm self-update --rollback 恢复",
+	"selfUpdate.rolledBack":      "已恢复 %s 处的旧可执行文件",
+	"selfUpdate.rollbackDryRun":  "将用 %[2]s 恢复 %[1]s",
+	"selfUpdate.failed":          "更新 atm 失败：%s",
+	"selfUpdate.notFound":        "该发布不存在",
+	"selfUpdate.noChecksum":      "该发布未提供 atm 二进制文件的校验和，无法校验",
+	"selfUpdate.noBackup":        "没有保存旧的可执行文件，无法回滚",
+	"selfUpdate.hint.notFound":   "对照仓库的发布检查版本号",
+	"selfUpdate.hint.noChecksum": "请自行从发布页面下载，或通过 --version 选择提供校验和的发布",
+	"selfUpdate.hint.permission": "atm 无法写入其安装目录；请以安装时的权限重新运行，或按安装方式更新",
+
+	// CLI
+	"cli.usage":              "用法：atm [--refresh] [命令] [选项]",
+	"cli.usage.prefix":       "用法：",
+	"cli.usage.install":      "atm install <工具>[@<版本>]...       安装工具，可指定版本",
+	"cli.usage.update":       "atm update <工具>... | --all         更新工具到最新版本",
+	"cli.usage.uninstall":    "atm uninstall <工具>...              卸载工具",
+	"cli.usage.versions":     "atm versions <工具> [--all]          列出工具已发布的版本",
+	"cli.usage.rollback":     "atm rollback <工具>...               恢复上次更新前安装的版本",
+	"cli.usage.history":      "atm history [--tool <工具>] [选项]   显示操作日志",
+	"cli.usage.list":         "atm list [-o json|yaml]              列出所有工具及其状态",
+	"cli.usage.outdated":     "atm outdated [-o json|yaml]          列出可更新的工具",
+	"cli.usage.lock":         "atm lock [--file <path>]             将已安装版本写入锁文件",
+	"cli.usage.sync":         "atm sync [--check] [--file <path>]   安装锁文件中的版本",
+	"cli.usage.doctor":       "atm doctor                           检查环境中的常见问题",
+	"cli.usage.dashboard":    "atm dashboard                        打开全屏仪表盘",
+	"cli.usage.selfUpdate":   "atm self-update [options]            将 atm 自身更新到最新发布",
+	"cli.usage.interactive":  "不带参数运行 atm 将进入交互式菜单。",
+	"cli.unknownCommand":     "未知命令：%s",
+	"cli.invalidOutput":      "不支持的输出格式：%s（可选 text、json 或 yaml）",
+	"cli.unknownTool":        "未知工具：%s",
+	"cli.alreadyInstalled":   "%s 已安装",
+	"cli.notInstalled":       "%s 未安装",
+	"cli.notInstalledStatus": "未安装",
+	"cli.upToDate":           "%s 已是最新 (v%s)",
+	"cli.latestUnknown":      "无法获取 %s 的最新版本",
+	"cli.pinned":             "%s 已固定版本，跳过",
+	"cli.notifySkipped":      "跳过 %s（可更新至 v%s）：其更新策略为 notify",
+
+	// Lock
+	"lock.resolving":            "正在获取完整性哈希...",
+	"lock.written":              "已写入 %s，共 %d 个工具",
+	"lock.integrityUnavailable": "无法从镜像源读取 %s v%s 的完整性哈希",
+	"sync.readError":            "读取锁文件失败：%s",
+	"sync.inSync":               "所有工具均与 %s 一致",
+	"sync.drift":                "%d 个工具与 %s 不一致：",
+	"sync.driftMissing":         "%s：未安装（锁定 v%s）",
+	"sync.driftVersion":         "%s：已安装 v%s，锁定 v%s",
+	"sync.installing":           "正在安装 %s v%s...",
+	"sync.success":              "%s 已切换到 v%s",
+	"sync.failed":               "同步 %s 失败：%s",
+	"sync.versionNotFound":      "镜像源中不存在版本 %s",
+	"sync.integrityMismatch":    "版本 %s 的完整性哈希与锁文件不一致",
+	"sync.unknownSource":        "锁文件中的来源 %q 无效",
+	"sync.sourceMismatch":       "锁文件中的来源为 %s，但目录中的来源为 %s",
+
+	// Versions
+	"versions.selectTool":       "选择工具：",
+	"versions.fetching":         "正在获取 %s 的版本...",
+	"versions.fetchFailed":      "读取 %s 的版本失败：%s",
+	"versions.none":             "未找到 %s 的已发布版本",
+	"versions.select":           "选择 %s 的版本：",
+	"versions.tagInstalled":     "已安装",
+	"versions.tagDeprecated":    "已弃用",
+	"versions.more":             "还有 %d 个更早的版本未显示；使用 --all 列出全部",
+	"versions.resolving":        "正在解析 %s@%s...",
+	"versions.alreadyAt":        "%s 已是 v%s",
+	"versions.confirmDowngrade": "将 %s 从 v%s 降级到 v%s？",
+	"versions.installing":       "正在安装 %s v%s...",
+	"versions.upgrading":        "正在将 %s 从 v%s 升级到 v%s...",
+	"versions.downgrading":      "正在将 %s 从 v%s 降级到 v%s...",
+	"versions.installed":        "已安装 %s v%s",
+	"versions.upgraded":         "已将 %s 升级到 v%s",
+	"versions.downgraded":       "已将 %s 降级到 v%s",
+	"versions.pinHint":          "如需保留此版本，请在工具目录中为 %s 设置 \"policy\": \"pinned\"",
+	"versions.npmOnly":          "版本列表仅支持 npm 包，不支持来源 %s",
+
+	// Rollback
+	"rollback.select":        "选择要回滚的工具：",
+	"rollback.noneAvailable": "没有可回滚的更新",
+	"rollback.noHistory":     "没有记录 %s 的先前版本",
+	"rollback.rollingBack":   "正在将 %s 回滚到 v%s...",
+	"rollback.success":       "已将 %s 从 v%s 回滚到 v%s",
+	"rollback.failed":        "回滚 %s 失败：%s",
+	"rollback.recordFailed":  "无法记录 %s 的先前版本：%s",
+	"rollback.historyError":  "读取更新历史失败：%s",
+
+	// History
+	"history.empty":       "没有记录任何操作",
+	"history.invalidDate": "无效的日期：%s（应为 YYYY-MM-DD 或 RFC 3339）",
+	"history.readError":   "读取操作日志失败：%s",
+	"history.writeError":  "无法写入操作日志：%s",
+
+	// Doctor
+	"doctor.summary":               "%d 项通过，%d 项警告，%d 项失败",
+	"doctor.label.prefix":          "全局目录",
+	"doctor.label.bin":             "全局 bin 目录",
+	"doctor.label.releaseBin":      "Release 二进制目录",
+	"doctor.label.registry":        "镜像源",
+	"doctor.label.proxy":           "代理",
+	"doctor.notFound":              "未在 PATH 中找到",
+	"doctor.versionFailed":         "位于 %s，但无法获取其版本：%s",
+	"doctor.nodeTooOld":            "%s 低于支持的最低版本 v%d",
+	"doctor.lookupFailed":          "无法确定：%s",
+	"doctor.prefixWritable":        "%s 可写",
+	"doctor.prefixReadOnly":        "当前用户无法写入 %s",
+	"doctor.onPath":                "%s 在 PATH 中",
+	"doctor.notOnPath":             "%s 不在 PATH 中",
+	"doctor.registryReachable":     "%s 可以访问",
+	"doctor.registryStatus":        "%s 返回 HTTP %d",
+	"doctor.registryUnreachable":   "无法访问 %s：%s",
+	"doctor.proxyNone":             "未配置代理",
+	"doctor.proxyNpmOnly":          "npm 使用代理 %s，但未设置 HTTPS_PROXY",
+	"doctor.proxyInvalid":          "%s 不是有效的代理地址",
+	"doctor.manifestError":         "读取包清单失败：%s",
+	"doctor.binMissing":            "未在 PATH 中找到",
+	"doctor.binShadowed":           "解析到 %s，而不是 %s 中的副本",
+	"doctor.hint.installNode":      "从 https://nodejs.org 安装 Node.js %d 或更高版本",
+	"doctor.hint.upgradeNode":      "将 Node.js 升级到 v%d 或更高版本",
+	"doctor.hint.installNpm":       "npm 随 Node.js 一起安装，请从 https://nodejs.org 重新安装 Node.js",
+	"doctor.hint.installBackend":   "安装 %s，或通过 ATM_PACKAGE_MANAGER 选择其他包管理器",
+	"doctor.hint.reinstallProgram": "重新安装 %s",
+	"doctor.hint.lookup":           "检查包管理器能否在当前 shell 中正常运行",
+	"doctor.hint.prefix":           "不要使用 sudo；将全局目录设置为你拥有的文件夹，例如 npm config set prefix ~/.npm-global",
+	"doctor.hint.addToPath":        "在 shell 配置文件中将 %s 添加到 PATH，然后打开新的终端",
+	"doctor.hint.network":          "检查网络连接、.npmrc 中的镜像源地址和代理设置",
+	"doctor.hint.auth":             "使用 npm login 登录，或检查 .npmrc 中的镜像源令牌",
+	"doctor.hint.registryError":    "镜像源出现问题，请稍后重试或使用其他镜像源",
+	"doctor.hint.proxyEnv":         "atm 只使用 HTTPS_PROXY 和 HTTP_PROXY 环境变量，请将其设置为相同的代理",
+	"doctor.hint.proxyInvalid":     "请使用类似 http://proxy.example.com:8080 的地址",
+	"doctor.hint.reinstall":        "使用 atm uninstall %[1]s 和 atm install %[1]s 重新安装",
+	"doctor.hint.binMissing":       "确保全局 bin 目录在 PATH 中，或重新安装 %s",
+	"doctor.hint.binShadowed":      "删除另一个副本，或将 %s 移到 PATH 中更靠前的位置",
+	"doctor.hint.installSource":    "安装 %s 以管理来源为 %s 的工具",
+
+	// Prefix
+	"prefix.denied":       "包管理器无权写入其全局目录（EACCES）。atm 不会使用 sudo 重试。",
+	"prefix.otherBackend": "将 %s 的全局目录设置为你拥有的文件夹，然后重试",
+	"prefix.explain":      "npm 可以将全局包安装到你拥有的目录 %s 中，无需管理员权限。已安装在旧目录中的工具会保留在原处。",
+	"prefix.confirm":      "将 npm 全局前缀设置为 %s，把 %s 添加到 PATH 并重试？",
+	"prefix.manual":       "如需手动修复，请运行 npm config set prefix %s，并将 %s 添加到 PATH",
+	"prefix.configured":   "npm 现在将全局包安装到 %s",
+	"prefix.pathAdded":    "已在 %[2]s 中将 %[1]s 添加到 PATH，请打开新的终端使用",
+	"prefix.pathManual":   "请在系统设置中将 %s 添加到用户 PATH",
+	"prefix.pathFailed":   "无法更新 shell 配置文件：%s。请自行将 %s 添加到 PATH",
+	"prefix.failed":       "配置 npm 前缀失败：%s",
+
+	// Errors
+	"errors.hint":                "提示：%s",
+	"errors.notFound":            "镜像源中不存在该包或版本",
+	"errors.permission":          "没有写入全局包目录的权限",
+	"errors.network":             "无法访问镜像源",
+	"errors.engine":              "该包不支持当前安装的 Node.js 版本",
+	"errors.npmMissing":          "%s 未安装或不在 PATH 中",
+	"errors.timeout":             "操作耗时过长，已停止",
+	"errors.interrupted":         "已被用户中断",
+	"errors.checksum":            "下载内容与发布提供的校验和不一致",
+	"errors.noChecksum":          "该发布未提供下载内容的校验和，无法校验",
+	"errors.hint.notFound":       "检查包名和版本，以及 .npmrc 中配置的镜像源",
+	"errors.hint.permission":     "不要使用 sudo；在终端中运行 atm 以配置用户自有的 npm 前缀，或运行 atm doctor",
+	"errors.hint.network":        "检查网络连接和代理设置（HTTPS_PROXY、npm 的 https-proxy），或运行 atm doctor",
+	"errors.hint.engine":         "升级 Node.js，或使用 atm install <工具>@<版本> 安装旧版本",
+	"errors.hint.npmMissing":     "安装该包管理器（npm 随 https://nodejs.org 的 Node.js 一起安装），或通过 ATM_PACKAGE_MANAGER 选择其他包管理器",
+	"errors.hint.timeout":        "通过 ATM_TIMEOUT（包操作）或 ATM_REGISTRY_TIMEOUT（镜像源请求）提高时限，例如 ATM_TIMEOUT=30m",
+	"errors.hint.commandMissing": "安装 %s 并确保其在 PATH 中",
+	"errors.hint.checksum":       "下载可能已损坏或被篡改；请重试，如仍失败请向项目反馈",
+	"errors.hint.noChecksum":     "请自行从发布页面下载二进制文件，或请项目发布校验和",
+
+	// Interrupt
+	"interrupt.interrupted":    "已中断：%s",
+	"interrupt.notInstalled":   "%s 未安装",
+	"interrupt.unchanged":      "%s 仍为 %s",
+	"interrupt.installed":      "已安装 %s %s",
+	"interrupt.removed":        "已移除 %s %s",
+	"interrupt.changed":        "%s 现为 %s（原为 %s）",
+	"interrupt.hint":           "重新运行该命令以完成操作；如果工具无法使用，请运行 atm doctor",
+	"interrupt.nothingChanged": "未做任何更改",
+
+	// Dashboard
+	"dashboard.title":              "ATM %s · %s",
+	"dashboard.summary":            "已安装 %d 个 · %d 个可更新",
+	"dashboard.colTool":            "工具",
+	"dashboard.colPackage":         "包名",
+	"dashboard.colStatus":          "状态",
+	"dashboard.colCurrent":         "已安装",
+	"dashboard.colLatest":          "最新",
+	"dashboard.installed":          "已安装",
+	"dashboard.notInstalled":       "未安装",
+	"dashboard.updateBadge":        "有可用更新",
+	"dashboard.pinnedBadge":        "已固定",
+	"dashboard.queuedBadge":        "排队中",
+	"dashboard.operations":         "操作",
+	"dashboard.help":               "↑/↓ 移动  i 安装  u 更新  U 全部更新  d 卸载  回车 详情  r 刷新  c 清除  q 退出",
+	"dashboard.detailsHelp":        "esc 返回  i 安装  u 更新  d 卸载  q 退出",
+	"dashboard.confirmUninstall":   "卸载 %s？(y/N)",
+	"dashboard.queued":             "%s 已有排队中的操作",
+	"dashboard.busy":               "有操作正在运行；请等待其完成，或按 Ctrl+C 取消",
+	"dashboard.cancelling":         "正在取消；再次按 Ctrl+C 立即退出",
+	"dashboard.cancelled":          "已取消",
+	"dashboard.notTerminal":        "仪表盘需要交互式终端",
+	"dashboard.job.check":          "检查最新版本",
+	"dashboard.job.refresh":        "刷新已安装的工具和版本",
+	"dashboard.job.install":        "安装 %s",
+	"dashboard.job.update":         "更新 %s",
+	"dashboard.job.uninstall":      "卸载 %s",
+	"dashboard.detail.package":     "包名",
+	"dashboard.detail.source":      "来源",
+	"dashboard.detail.description": "描述",
+	"dashboard.detail.status":      "状态",
+	"dashboard.detail.current":     "已安装版本",
+	"dashboard.detail.latest":      "最新版本",
+	"dashboard.detail.constraint":  "版本约束",
+	"dashboard.detail.policy":      "更新策略",
+	"dashboard.detail.bins":        "可执行文件",
 
 	// Config
 	"config.loadError": "加载配置失败",
@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/xiaoxu123195/atm/pkg/config"
+)
+
+// FileName is the default lockfile name
+const FileName = "atm.lock"
+
+// lockfileVersion identifies the layout of the lockfile
+const lockfileVersion = 1
+
+// Lockfile pins the exact versions of a set of tools
+type Lockfile struct {
+	LockfileVersion int     `json:"lockfileVersion"`
+	Tools           []Entry `json:"tools"`
+}
+
+// Entry pins one tool to an exact version
+type Entry struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	// Source is where the package comes from (pipx, cargo, go or
+	// github-release); empty means npm
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version"`
+	// Integrity is the registry's Subresource Integrity hash of the version's tarball
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// Drift describes a tool whose installed version differs from the lockfile
+type Drift struct {
+	Entry
+	// InstalledVersion is empty when the tool is not installed
+	InstalledVersion string
+}
+
+// New creates an empty lockfile
+func New() *Lockfile {
+	return &Lockfile{
+		LockfileVersion: lockfileVersion,
+		Tools:           []Entry{},
+	}
+}
+
+// Read loads a lockfile
+func Read(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lockfile Lockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if lockfile.LockfileVersion != lockfileVersion {
+		return nil, fmt.Errorf("%s: unsupported lockfileVersion %d", path, lockfile.LockfileVersion)
+	}
+
+	for i, entry := range lockfile.Tools {
+		if entry.Package == "" || entry.Version == "" {
+			return nil, fmt.Errorf("%s: tool #%d needs a package and a version", path, i+1)
+		}
+		if entry.Source != "" && !slices.Contains(config.SourceNames, entry.Source) {
+			return nil, fmt.Errorf("%s: tool %q: unknown source %q", path, entry.Package, entry.Source)
+		}
+		if !config.ExactVersion(entry.Source, entry.Version) {
+			return nil, fmt.Errorf("%s: tool %q: %q is not an exact version", path, entry.Package, entry.Version)
+		}
+	}
+
+	return &lockfile, nil
+}
+
+// Write saves the lockfile as indented JSON
+func (l *Lockfile) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Drift compares the lockfile with the installed versions (package name to
+// version) and returns the entries that do not match, in lockfile order
+func (l *Lockfile) Drift(installed map[string]string) []Drift {
+	var drift []Drift
+	for _, entry := range l.Tools {
+		if version := installed[entry.Package]; version != entry.Version {
+			drift = append(drift, Drift{Entry: entry, InstalledVersion: version})
+		}
+	}
+	return drift
+}
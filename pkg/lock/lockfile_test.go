@@ -0,0 +1,107 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeLockfile writes data to a lockfile in a temporary directory
+func writeLockfile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", `{"lockfileVersion": 1, "tools": [{"name": "Codex", "package": "@openai/codex", "version": "0.5.0"}, {"name": "Aider", "package": "aider-chat", "source": "pipx", "version": "0.86.1"}]}`, ""},
+		{"go pseudo-version", `{"lockfileVersion": 1, "tools": [{"package": "example.com/cmd/tool", "source": "go", "version": "v0.0.0-20240101000000-abcdef123456"}]}`, ""},
+		{"no tools", `{"lockfileVersion": 1}`, ""},
+		{"invalid JSON", `{"lockfileVersion": 1,}`, "invalid character"},
+		{"missing lockfileVersion", `{"tools": []}`, "unsupported lockfileVersion 0"},
+		{"newer lockfileVersion", `{"lockfileVersion": 2, "tools": []}`, "unsupported lockfileVersion 2"},
+		{"missing package", `{"lockfileVersion": 1, "tools": [{"version": "1.0.0"}]}`, "tool #1 needs a package and a version"},
+		{"missing version", `{"lockfileVersion": 1, "tools": [{"package": "a", "version": "1.0.0"}, {"package": "b"}]}`, "tool #2 needs a package and a version"},
+		{"range instead of a version", `{"lockfileVersion": 1, "tools": [{"package": "@openai/codex", "version": "^0.5.0"}]}`, `"^0.5.0" is not an exact version`},
+		{"dist-tag instead of a version", `{"lockfileVersion": 1, "tools": [{"package": "@openai/codex", "version": "latest"}]}`, `"latest" is not an exact version`},
+		{"bad version of another source", `{"lockfileVersion": 1, "tools": [{"package": "aider-chat", "source": "pipx", "version": ">=0.86"}]}`, `">=0.86" is not an exact version`},
+		{"unknown source", `{"lockfileVersion": 1, "tools": [{"package": "tool", "source": "apt", "version": "1.0.0"}]}`, `unknown source "apt"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLockfile(t, tt.data)
+			lockfile, err := Read(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if lockfile.LockfileVersion != lockfileVersion {
+					t.Errorf("lockfileVersion = %d", lockfile.LockfileVersion)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.HasPrefix(err.Error(), path+": ") {
+				t.Errorf("Read error = %v, want %q prefixed with the path", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	lockfile := New()
+	lockfile.Tools = append(lockfile.Tools,
+		Entry{Name: "Codex", Package: "@openai/codex", Version: "0.5.0", Integrity: "sha512-abc"},
+		Entry{Name: "Goose", Package: "block/goose", Source: "github-release", Version: "1.0.0"},
+	)
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := lockfile.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, lockfile) {
+		t.Errorf("Read = %+v, want %+v", read, lockfile)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	lockfile := New()
+	lockfile.Tools = []Entry{
+		{Package: "a", Version: "1.0.0"},
+		{Package: "b", Version: "2.0.0"},
+		{Package: "c", Source: "cargo", Version: "3.0.0"},
+	}
+	tests := []struct {
+		name      string
+		installed map[string]string
+		want      []Drift
+	}{
+		{"in sync", map[string]string{"a": "1.0.0", "b": "2.0.0", "c": "3.0.0"}, nil},
+		{"extra tools are ignored", map[string]string{"a": "1.0.0", "b": "2.0.0", "c": "3.0.0", "d": "4.0.0"}, nil},
+		{"missing tool", map[string]string{"a": "1.0.0", "c": "3.0.0"}, []Drift{{Entry: lockfile.Tools[1]}}},
+		{"version mismatch", map[string]string{"a": "1.1.0", "b": "2.0.0", "c": "2.9.0"}, []Drift{
+			{Entry: lockfile.Tools[0], InstalledVersion: "1.1.0"},
+			{Entry: lockfile.Tools[2], InstalledVersion: "2.9.0"},
+		}},
+		{"nothing installed", nil, []Drift{{Entry: lockfile.Tools[0]}, {Entry: lockfile.Tools[1]}, {Entry: lockfile.Tools[2]}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockfile.Drift(tt.installed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Drift = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
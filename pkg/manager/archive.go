@@ -0,0 +1,246 @@
+package manager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// extractAsset installs the executables named bins (without .exe) of a
+// downloaded asset into dir and returns their file names. Archives are
+// unpacked, keeping only those files; a bare binary is named after bins[0].
+func extractAsset(file, assetName, dir string, bins []string) ([]string, error) {
+	name := strings.ToLower(assetName)
+	switch {
+	case hasAnySuffix(name, []string{".tar.gz", ".tgz"}):
+		return extractTar(file, dir, bins, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) })
+	case hasAnySuffix(name, []string{".tar.bz2", ".tbz2"}):
+		return extractTar(file, dir, bins, func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil })
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(file, dir, bins)
+	}
+
+	binary := binaryName(bins[0])
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	if err := installFile(in, filepath.Join(dir, binary)); err != nil {
+		return nil, err
+	}
+	return []string{binary}, nil
+}
+
+// extractTar installs the executables named bins from a compressed tar archive
+func extractTar(file, dir string, bins []string, decompress func(io.Reader) (io.Reader, error)) ([]string, error) {
+	open := func() (*tar.Reader, io.Closer, error) {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, err := decompress(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return tar.NewReader(r), f, nil
+	}
+
+	// Find the executables first, then unpack them in a second pass
+	archive, closer, err := open()
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			closer.Close()
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			entries = append(entries, header.Name)
+		}
+	}
+	closer.Close()
+
+	wanted, err := executables(entries, bins)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, closer, err = open()
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var files []string
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !wanted[header.Name] {
+			continue
+		}
+		base := path.Base(header.Name)
+		if err := installFile(archive, filepath.Join(dir, base)); err != nil {
+			return nil, err
+		}
+		files = append(files, base)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// extractZip installs the executables named bins from a zip archive
+func extractZip(file, dir string, bins []string) ([]string, error) {
+	archive, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer archive.Close()
+
+	var entries []string
+	for _, f := range archive.File {
+		if f.Mode().IsRegular() {
+			entries = append(entries, f.Name)
+		}
+	}
+	wanted, err := executables(entries, bins)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range archive.File {
+		if !wanted[f.Name] {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		base := path.Base(f.Name)
+		err = installFile(r, filepath.Join(dir, base))
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, base)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// executables selects the files of an archive to install: for each of bins,
+// the first regular file of that name in any directory. Other files, such as
+// completions or helper scripts, are left out, and so is the permission
+// bit, which zip archives often do not carry.
+func executables(entries, bins []string) (map[string]bool, error) {
+	wanted := make(map[string]bool)
+	var missing []string
+	for _, bin := range bins {
+		found := false
+		for _, entry := range entries {
+			if path.Base(entry) == binaryName(bin) {
+				wanted[entry], found = true, true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, binaryName(bin))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the archive contains no %s", strings.Join(missing, ", "))
+	}
+	return wanted, nil
+}
+
+// binaryName returns the file name of an executable on this platform
+func binaryName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// installFile writes an executable through a temporary file, so that a
+// running copy is replaced instead of overwritten
+func installFile(r io.Reader, target string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".install-*")
+	if err != nil {
+		return fileError(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(target), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fileError(err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return fileError(err)
+	}
+	return fileError(os.Rename(tmp.Name(), target))
+}
+
+// writeFileAtomic writes a file through a temporary file in the same directory
+func writeFileAtomic(target string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// fileError marks a failed file operation as a permission error when it was one
+func fileError(err error) error {
+	if err != nil && errors.Is(err, os.ErrPermission) {
+		return withKind(err, ErrPermission)
+	}
+	return err
+}
+
+// hasAnySuffix reports whether s ends with any of the suffixes
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
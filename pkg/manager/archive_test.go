@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// archiveFile is a file written into a test archive
+type archiveFile struct {
+	name string
+	mode int64
+	data string
+}
+
+// tarGz builds a gzip-compressed tar archive
+func tarGz(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		header := &tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipArchive builds a zip archive whose files carry no permissions
+func zipArchive(t *testing.T, files []archiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// extractTestAsset writes an asset to a file and extracts it into a new directory
+func extractTestAsset(t *testing.T, assetName string, data []byte, bins []string) (string, []string, error) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), assetName)
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files, err := extractAsset(file, assetName, dir, bins)
+	return dir, files, err
+}
+
+// dirFiles lists the names in a directory
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestExtractAsset(t *testing.T) {
+	files := []archiveFile{
+		{"tool-1.0/" + binaryName("tool"), 0o755, "tool binary"},
+		{"tool-1.0/" + binaryName("tool-helper"), 0o755, "helper binary"},
+		{"tool-1.0/install.sh", 0o755, "#!/bin/sh"},
+		{"tool-1.0/README.md", 0o644, "# tool"},
+	}
+	tests := []struct {
+		name      string
+		assetName string
+		data      []byte
+		bins      []string
+		want      []string
+	}{
+		{"tar.gz", "tool.tar.gz", tarGz(t, files), []string{"tool"}, []string{binaryName("tool")}},
+		{"zip without permissions", "tool.zip", zipArchive(t, files), []string{"tool"}, []string{binaryName("tool")}},
+		{"several bins", "tool.tgz", tarGz(t, files), []string{"tool-helper", "tool"}, []string{binaryName("tool"), binaryName("tool-helper")}},
+		{"bare binary", "tool-linux-amd64", []byte("tool binary"), []string{"tool"}, []string{binaryName("tool")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, got, err := extractTestAsset(t, tt.assetName, tt.data, tt.bins)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+			if files := dirFiles(t, dir); !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("directory holds %v, want only %v", files, tt.want)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, binaryName("tool")))
+			if err != nil || string(data) != "tool binary" {
+				t.Errorf("%s = %q, %v; want the binary", binaryName("tool"), data, err)
+			}
+		})
+	}
+}
+
+func TestExtractAssetMissingBinary(t *testing.T) {
+	data := tarGz(t, []archiveFile{{"bin/other", 0o755, "other binary"}})
+	dir, _, err := extractTestAsset(t, "tool.tar.gz", data, []string{"tool"})
+	if err == nil {
+		t.Fatal("extracted an archive without the binary")
+	}
+	if files := dirFiles(t, dir); len(files) != 0 {
+		t.Errorf("directory holds %v after the failure", files)
+	}
+}
+
+func TestExecutablesTakesFirstMatch(t *testing.T) {
+	entries := []string{"a/" + binaryName("tool"), "b/" + binaryName("tool"), "docs/tool.1"}
+	got, err := executables(entries, []string{"tool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{entries[0]: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("executables = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,329 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackendEnv is the environment variable that overrides the detected package manager
+const BackendEnv = "ATM_PACKAGE_MANAGER"
+
+// cancelGracePeriod is how long an interrupted package manager has to exit
+// before it is killed
+const cancelGracePeriod = 5 * time.Second
+
+// outputKey is the context key of the writer set by WithOutput
+type outputKey struct{}
+
+// WithOutput returns a context under which package manager commands also copy
+// their output to w while they run, e.g. to show the progress of an install
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// BackendNames lists the supported package managers in auto-detection order
+var BackendNames = []string{"npm", "pnpm", "bun", "yarn"}
+
+// Backend performs global package operations with a specific package manager.
+// Commands started by its methods are stopped when ctx is done. Latest
+// versions are read from the npm registry by RegistryClient rather than
+// through the package manager, so a backend has no operation for them.
+type Backend interface {
+	// Name returns the package manager executable name
+	Name() string
+	// Install installs a package spec (name or name@version) globally
+	Install(ctx context.Context, packageSpec string) error
+	// Update updates a globally installed package to its latest version
+	Update(ctx context.Context, packageName string) error
+	// Uninstall removes a globally installed package
+	Uninstall(ctx context.Context, packageName string) error
+	// InstalledVersion returns the version in the global store
+	InstalledVersion(ctx context.Context, packageName string) (string, error)
+	// InstalledPackages returns every package in the global store with its version
+	InstalledPackages(ctx context.Context) (map[string]string, error)
+	// GlobalRoot returns the global node_modules directory
+	GlobalRoot(ctx context.Context) (string, error)
+	// GlobalBin returns the directory global package executables are linked into
+	GlobalBin(ctx context.Context) (string, error)
+}
+
+// PrefixSetter is implemented by backends whose global directory can be
+// moved to a user-owned prefix
+type PrefixSetter interface {
+	SetPrefix(ctx context.Context, dir string) error
+}
+
+// UserPrefix returns the user-owned prefix offered when the global directory
+// is not writable (~/.npm-global)
+func UserPrefix() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".npm-global"), nil
+}
+
+// NewBackend returns the backend with the given name
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "npm":
+		return &NpmBackend{}, nil
+	case "pnpm":
+		return &PnpmBackend{}, nil
+	case "yarn":
+		return &YarnBackend{}, nil
+	case "bun":
+		return &BunBackend{}, nil
+	default:
+		return nil, fmt.Errorf("unknown package manager %q (expected one of: %s)", name, strings.Join(BackendNames, ", "))
+	}
+}
+
+// DetectBackend selects the backend to use. ATM_PACKAGE_MANAGER takes
+// precedence over preferred (the configured value). Otherwise the package
+// manager on PATH whose global store holds the most of packages (the catalog)
+// is used; when none holds any, the first one found on PATH in BackendNames
+// order, falling back to npm.
+func DetectBackend(ctx context.Context, preferred string, packages []string) (Backend, error) {
+	if name := os.Getenv(BackendEnv); name != "" {
+		return NewBackend(name)
+	}
+	if preferred != "" {
+		return NewBackend(preferred)
+	}
+
+	var found []Backend
+	for _, name := range BackendNames {
+		if _, err := exec.LookPath(name); err == nil {
+			backend, _ := NewBackend(name)
+			found = append(found, backend)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return &NpmBackend{}, nil
+	case 1:
+		return found[0], nil
+	}
+
+	// Ask every package manager for its global store at once
+	counts := make([]int, len(found))
+	var wg sync.WaitGroup
+	for i, backend := range found {
+		wg.Add(1)
+		go func(i int, backend Backend) {
+			defer wg.Done()
+			counts[i] = storedPackages(ctx, backend, packages)
+		}(i, backend)
+	}
+	wg.Wait()
+
+	best := 0
+	for i := range found {
+		if counts[i] > counts[best] {
+			best = i
+		}
+	}
+	return found[best], nil
+}
+
+// storedPackages counts how many of packages are in the global store of a backend
+func storedPackages(ctx context.Context, backend Backend, packages []string) int {
+	root, err := backend.GlobalRoot(ctx)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, packageName := range packages {
+		if manifestVersion(filepath.Join(root, filepath.FromSlash(packageName))) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// globalStore resolves a package manager's global node_modules directory once
+// and reads installed versions from the package.json files inside it
+type globalStore struct {
+	once sync.Once
+	root string
+	err  error
+}
+
+// resolve returns the global node_modules directory, calling find only once
+func (g *globalStore) resolve(ctx context.Context, find func(context.Context) (string, error)) (string, error) {
+	g.once.Do(func() {
+		g.root, g.err = find(ctx)
+	})
+	return g.root, g.err
+}
+
+// installedVersion returns the version of a package in the global store
+func (g *globalStore) installedVersion(ctx context.Context, find func(context.Context) (string, error), packageName string) (string, error) {
+	root, err := g.resolve(ctx, find)
+	if err != nil {
+		return "", err
+	}
+
+	version := manifestVersion(filepath.Join(root, filepath.FromSlash(packageName)))
+	if version == "" {
+		return "", ErrNotFound
+	}
+	return version, nil
+}
+
+// installedPackages lists the global store in a single pass, including scoped packages
+func (g *globalStore) installedPackages(ctx context.Context, find func(context.Context) (string, error)) (map[string]string, error) {
+	root, err := g.resolve(ctx, find)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Nothing has been installed globally yet
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	packages := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if !strings.HasPrefix(name, "@") {
+			if version := manifestVersion(filepath.Join(root, name)); version != "" {
+				packages[name] = version
+			}
+			continue
+		}
+
+		scoped, err := os.ReadDir(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		for _, entry := range scoped {
+			if version := manifestVersion(filepath.Join(root, name, entry.Name())); version != "" {
+				packages[name+"/"+entry.Name()] = version
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+// PackageBins returns the executable names a package in the global store
+// declares in the "bin" field of its package.json
+func PackageBins(globalRoot, packageName string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(globalRoot, filepath.FromSlash(packageName), "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Name string          `json:"name"`
+		Bin  json.RawMessage `json:"bin"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	// "bin" is either a single path, named after the package, or a map of names to paths
+	var single string
+	if json.Unmarshal(manifest.Bin, &single) == nil {
+		return []string{manifest.Name[strings.LastIndex(manifest.Name, "/")+1:]}, nil
+	}
+
+	var named map[string]string
+	if err := json.Unmarshal(manifest.Bin, &named); err != nil {
+		return nil, nil
+	}
+	bins := make([]string, 0, len(named))
+	for name := range named {
+		bins = append(bins, name)
+	}
+	sort.Strings(bins)
+	return bins, nil
+}
+
+// CommandVersion returns the output of `name --version`, or of `go version`
+func CommandVersion(ctx context.Context, name string) (string, error) {
+	if name == "go" {
+		return run(ctx, name, "version")
+	}
+	return run(ctx, name, "--version")
+}
+
+// manifestVersion reads the version from a package directory's package.json
+func manifestVersion(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ""
+	}
+	return manifest.Version
+}
+
+// run executes a command and returns its trimmed stdout. On failure the
+// returned error is a CommandError carrying the command's stderr, or wraps
+// ErrNpmMissing if the command is not installed. When ctx is done the command
+// is interrupted, then killed if it does not exit within cancelGracePeriod,
+// and the error wraps ctx.Err().
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		// Give npm the chance to clean up its partial work
+		if runtime.GOOS == "windows" {
+			return cmd.Process.Kill()
+		}
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = cancelGracePeriod
+
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		cmd.Stdout = io.MultiWriter(&out, w)
+		cmd.Stderr = io.MultiWriter(&errOut, w)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), ctx.Err())
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", withKind(err, ErrNpmMissing)
+		}
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			code, kind := classify(msg)
+			return "", &CommandError{Code: code, Stderr: msg, Kind: kind}
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeCommand writes a shell script named name into dir that prints output
+func fakeCommand(t *testing.T, dir, name, output string) {
+	t.Helper()
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeStore creates a global node_modules directory holding packages
+func fakeStore(t *testing.T, packages ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, packageName := range packages {
+		dir := filepath.Join(root, filepath.FromSlash(packageName))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"version": "1.0.0"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetectBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as package managers")
+	}
+	catalog := []string{"@openai/codex", "@anthropic-ai/claude-code"}
+	tests := []struct {
+		name      string
+		npm       []string
+		pnpm      []string
+		preferred string
+		env       string
+		want      string
+	}{
+		{"store holding the catalog", []string{"left-pad"}, []string{"@openai/codex"}, "", "", "pnpm"},
+		{"store holding more of the catalog", []string{"@openai/codex"}, catalog, "", "", "pnpm"},
+		{"tie keeps PATH order", catalog, catalog, "", "", "npm"},
+		{"empty stores keep PATH order", nil, nil, "", "", "npm"},
+		{"configured", nil, catalog, "npm", "", "npm"},
+		{"environment over configured", nil, nil, "npm", "yarn", "yarn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin := t.TempDir()
+			fakeCommand(t, bin, "npm", fakeStore(t, tt.npm...))
+			fakeCommand(t, bin, "pnpm", fakeStore(t, tt.pnpm...))
+			t.Setenv("PATH", bin)
+			t.Setenv(BackendEnv, tt.env)
+
+			backend, err := DetectBackend(context.Background(), tt.preferred, catalog)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backend.Name() != tt.want {
+				t.Errorf("DetectBackend = %s, want %s", backend.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectBackendWithoutPackageManagers(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv(BackendEnv, "")
+	backend, err := DetectBackend(context.Background(), "", []string{"@openai/codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backend.Name() != "npm" {
+		t.Errorf("DetectBackend = %s, want npm", backend.Name())
+	}
+}
@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// BunBackend manages global packages with Bun
+type BunBackend struct {
+	store globalStore
+}
+
+// Name returns the package manager executable name
+func (b *BunBackend) Name() string {
+	return "bun"
+}
+
+// Install installs a package globally
+func (b *BunBackend) Install(ctx context.Context, packageSpec string) error {
+	_, err := run(ctx, "bun", "add", "-g", packageSpec)
+	return err
+}
+
+// Update updates a package to the latest version
+func (b *BunBackend) Update(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "bun", "add", "-g", packageName+"@latest")
+	return err
+}
+
+// Uninstall removes a global package
+func (b *BunBackend) Uninstall(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "bun", "remove", "-g", packageName)
+	return err
+}
+
+// InstalledVersion returns the version installed in the global store
+func (b *BunBackend) InstalledVersion(ctx context.Context, packageName string) (string, error) {
+	return b.store.installedVersion(ctx, b.globalRoot, packageName)
+}
+
+// InstalledPackages lists the global store
+func (b *BunBackend) InstalledPackages(ctx context.Context) (map[string]string, error) {
+	return b.store.installedPackages(ctx, b.globalRoot)
+}
+
+// GlobalRoot returns the global node_modules directory
+func (b *BunBackend) GlobalRoot(ctx context.Context) (string, error) {
+	return b.store.resolve(ctx, b.globalRoot)
+}
+
+// GlobalBin returns the directory global executables are linked into ($BUN_INSTALL/bin or ~/.bun/bin)
+func (b *BunBackend) GlobalBin(context.Context) (string, error) {
+	dir, err := bunInstallDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bin"), nil
+}
+
+// globalRoot returns Bun's global node_modules directory
+// ($BUN_INSTALL/install/global/node_modules, default ~/.bun)
+func (b *BunBackend) globalRoot(context.Context) (string, error) {
+	dir, err := bunInstallDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "install", "global", "node_modules"), nil
+}
+
+// bunInstallDir returns bun's installation directory ($BUN_INSTALL or ~/.bun)
+func bunInstallDir() (string, error) {
+	if dir := os.Getenv("BUN_INSTALL"); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".bun"), nil
+}
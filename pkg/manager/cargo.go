@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CratesURL is the API of the Rust package registry
+const CratesURL = "https://crates.io/api/v1/crates/"
+
+// CargoInstaller installs Rust binaries from crates.io with cargo install
+type CargoInstaller struct {
+	client *http.Client
+}
+
+// Name returns the source name
+func (c *CargoInstaller) Name() string {
+	return "cargo"
+}
+
+// Command returns the executable the installer runs
+func (c *CargoInstaller) Command() string {
+	return "cargo"
+}
+
+// Install builds and installs a crate. cargo replaces an installed version
+// when a different one is requested.
+func (c *CargoInstaller) Install(ctx context.Context, crate, version string) error {
+	args := []string{"install", "--locked", crate}
+	if version != "" {
+		args = append(args, "--version", strings.TrimPrefix(version, "v"))
+	}
+	_, err := run(ctx, "cargo", args...)
+	return err
+}
+
+// Update installs the latest version of a crate if it is newer
+func (c *CargoInstaller) Update(ctx context.Context, crate string) error {
+	return c.Install(ctx, crate, "")
+}
+
+// Uninstall removes the binaries of a crate
+func (c *CargoInstaller) Uninstall(ctx context.Context, crate string) error {
+	_, err := run(ctx, "cargo", "uninstall", crate)
+	return err
+}
+
+// InstalledVersions reads the installed crates from `cargo install --list`,
+// which prints a "name v1.2.3:" line per crate followed by its binaries
+func (c *CargoInstaller) InstalledVersions(ctx context.Context, crates []string) (map[string]string, error) {
+	output, err := run(ctx, "cargo", "install", "--list")
+	if err != nil {
+		return nil, err
+	}
+	return cargoVersions(output, crates), nil
+}
+
+// cargoVersions reads the versions of crates from the output of
+// `cargo install --list`. Crates installed from git or a path carry their
+// source after the version.
+func cargoVersions(output string, crates []string) map[string]string {
+	installed := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) >= 2 {
+			installed[fields[0]] = strings.TrimSuffix(strings.TrimPrefix(fields[1], "v"), ":")
+		}
+	}
+
+	versions := make(map[string]string)
+	for _, crate := range crates {
+		if version, ok := installed[crate]; ok {
+			versions[crate] = version
+		}
+	}
+	return versions
+}
+
+// Repository reads the repository of a crate from crates.io
+func (c *CargoInstaller) Repository(ctx context.Context, crate string) (Repository, error) {
+	var info struct {
+		Crate struct {
+			Repository string `json:"repository"`
+		} `json:"crate"`
+	}
+	if err := getJSON(ctx, c.client, CratesURL+url.PathEscape(crate), &info); err != nil {
+		return Repository{}, err
+	}
+	return Repository{URL: info.Crate.Repository}, nil
+}
+
+// LatestVersion reads the newest stable version of a crate from crates.io
+func (c *CargoInstaller) LatestVersion(ctx context.Context, crate string) (string, error) {
+	var info struct {
+		Crate struct {
+			MaxStableVersion string `json:"max_stable_version"`
+			MaxVersion       string `json:"max_version"`
+		} `json:"crate"`
+	}
+	if err := getJSON(ctx, c.client, CratesURL+url.PathEscape(crate), &info); err != nil {
+		return "", err
+	}
+	if info.Crate.MaxStableVersion != "" {
+		return info.Crate.MaxStableVersion, nil
+	}
+	return info.Crate.MaxVersion, nil
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cargoList is the output of `cargo install --list` with crates from
+// crates.io, git and a local path
+const cargoList = `cargo-update v13.4.0:
+    cargo-install-update
+    cargo-install-update-config
+ripgrep v14.1.0:
+    rg
+difftastic v0.58.0 (https://github.com/Wilfred/difftastic#1a2b3c4d):
+    difft
+tool v0.1.0 (/home/dev/src/tool):
+    tool
+`
+
+func TestCargoVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		crates []string
+		want   map[string]string
+	}{
+		{"crates.io", cargoList, []string{"ripgrep", "cargo-update"}, map[string]string{"ripgrep": "14.1.0", "cargo-update": "13.4.0"}},
+		{"git source", cargoList, []string{"difftastic"}, map[string]string{"difftastic": "0.58.0"}},
+		{"path source", cargoList, []string{"tool"}, map[string]string{"tool": "0.1.0"}},
+		{"binary names are not crates", cargoList, []string{"rg", "difft"}, map[string]string{}},
+		{"not installed", cargoList, []string{"bat"}, map[string]string{}},
+		{"CRLF line endings", "ripgrep v14.1.0:\r\n    rg\r\n", []string{"ripgrep"}, map[string]string{"ripgrep": "14.1.0"}},
+		{"nothing installed", "", []string{"ripgrep"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cargoVersions(tt.output, tt.crates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cargoVersions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Kinds of package manager failures. Errors returned by this package wrap one
+// of them when the failure was recognized; test with errors.Is.
+var (
+	// ErrNotFound means the package, or the requested version of it, does not exist
+	ErrNotFound = errors.New("package not found")
+	// ErrPermission means the package manager was not allowed to write to its
+	// global directory (EACCES or EPERM)
+	ErrPermission = errors.New("permission denied")
+	// ErrNetwork means the registry could not be reached
+	ErrNetwork = errors.New("network error")
+	// ErrEngine means the package does not support the installed Node.js version
+	ErrEngine = errors.New("unsupported engine")
+	// ErrNpmMissing means the package manager executable is not installed
+	ErrNpmMissing = errors.New("package manager not found")
+)
+
+// CommandError is a failed package manager command. It prints as the
+// command's stderr and unwraps to the kind of failure, if one was recognized.
+type CommandError struct {
+	// Code is the error code the package manager reported, e.g. E404 or EACCES
+	Code   string
+	Stderr string
+	Kind   error
+}
+
+func (e *CommandError) Error() string {
+	return e.Stderr
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Kind
+}
+
+// kindError marks an error as a kind of failure without changing its message
+type kindError struct {
+	err  error
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// withKind marks err as a kind of failure
+func withKind(err, kind error) error {
+	return &kindError{err: err, kind: kind}
+}
+
+// codePattern matches the error code line of npm ("npm ERR! code E404", or
+// "npm error code E404" since npm 10) and the ERR_PNPM_* codes of pnpm
+var codePattern = regexp.MustCompile(`(?m)^npm (?:ERR!|error) code (\S+)|\b(ERR_PNPM_[A-Z0-9_]+)\b`)
+
+// codeKinds maps the error codes of npm, pnpm and Node.js to kinds of failure
+var codeKinds = map[string]error{
+	"E404":                         ErrNotFound,
+	"ETARGET":                      ErrNotFound,
+	"ERR_PNPM_FETCH_404":           ErrNotFound,
+	"ERR_PNPM_NO_MATCHING_VERSION": ErrNotFound,
+	"EACCES":                       ErrPermission,
+	"EPERM":                        ErrPermission,
+	"ENOTFOUND":                    ErrNetwork,
+	"EAI_AGAIN":                    ErrNetwork,
+	"ECONNREFUSED":                 ErrNetwork,
+	"ECONNRESET":                   ErrNetwork,
+	"ETIMEDOUT":                    ErrNetwork,
+	"ENETUNREACH":                  ErrNetwork,
+	"EHOSTUNREACH":                 ErrNetwork,
+	"ERR_SOCKET_TIMEOUT":           ErrNetwork,
+	"ERR_PNPM_META_FETCH_FAIL":     ErrNetwork,
+	"EBADENGINE":                   ErrEngine,
+	"ENOTSUP":                      ErrEngine,
+	"ERR_PNPM_UNSUPPORTED_ENGINE":  ErrEngine,
+}
+
+// messageKinds recognizes failures from package managers that print no code
+// line (Yarn, Bun, pipx, cargo, go), checked in order against the lower-cased
+// stderr. The phrases are specific to those tools: stderr also carries the
+// output of install scripts, e.g. "sh: 1: node-gyp: not found".
+var messageKinds = []struct {
+	text string
+	kind error
+}{
+	{"eacces", ErrPermission},
+	{"eperm", ErrPermission},
+	{"permission denied", ErrPermission},
+	{"incompatible with this module", ErrEngine},
+	{"unsupported engine", ErrEngine},
+	{"enotfound", ErrNetwork},
+	{"eai_again", ErrNetwork},
+	{"econnrefused", ErrNetwork},
+	{"connectionrefused", ErrNetwork},
+	{"etimedout", ErrNetwork},
+	{"could not resolve host", ErrNetwork},
+	{"no such host", ErrNetwork},
+	{"failed to establish a new connection", ErrNetwork},
+	// Yarn
+	{"couldn't find package", ErrNotFound},
+	{"couldn't find any versions", ErrNotFound},
+	{`: not found".`, ErrNotFound},
+	// Bun
+	{" - 404", ErrNotFound},
+	{"no version matching", ErrNotFound},
+	// pipx
+	{"no matching distribution", ErrNotFound},
+	// cargo
+	{"could not find `", ErrNotFound},
+	// go
+	{": 404 not found", ErrNotFound},
+	{"no matching versions for query", ErrNotFound},
+	{"unknown revision", ErrNotFound},
+}
+
+// classify recognizes the error code and kind of failure from a command's
+// stderr. When npm or pnpm printed a code, only the code is used.
+func classify(stderr string) (string, error) {
+	if m := codePattern.FindStringSubmatch(stderr); m != nil {
+		code := m[1] + m[2]
+		return code, codeKinds[code]
+	}
+	return classifyMessage(stderr)
+}
+
+// classifyMessage recognizes a kind of failure from the text of stderr
+func classifyMessage(stderr string) (string, error) {
+	lower := strings.ToLower(stderr)
+	for _, m := range messageKinds {
+		if strings.Contains(lower, m.text) {
+			return "", m.kind
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		wantCode string
+		wantKind error
+	}{
+		// npm
+		{"npm 404", "npm error code E404\nnpm error 404 Not Found - GET https://registry.npmjs.org/nonexistent-pkg-xyz - Not found\nnpm error 404\nnpm error 404  'nonexistent-pkg-xyz@*' is not in this registry.", "E404", ErrNotFound},
+		{"npm missing version", "npm error code ETARGET\nnpm error notarget No matching version found for @openai/codex@99.0.0.", "ETARGET", ErrNotFound},
+		{"npm 6 permission", "npm ERR! code EACCES\nnpm ERR! syscall mkdir\nnpm ERR! path /usr/local/lib/node_modules/@openai\nnpm ERR! errno -13\nnpm ERR! Error: EACCES: permission denied, mkdir '/usr/local/lib/node_modules/@openai'", "EACCES", ErrPermission},
+		{"npm offline", "npm error code ENOTFOUND\nnpm error syscall getaddrinfo\nnpm error errno ENOTFOUND\nnpm error network request to https://registry.npmjs.org/@openai%2fcodex failed, reason: getaddrinfo ENOTFOUND registry.npmjs.org", "ENOTFOUND", ErrNetwork},
+		{"npm engine", "npm error code EBADENGINE\nnpm error engine Unsupported engine\nnpm error engine Not compatible with your version of node/npm: @openai/codex@0.5.0\nnpm error notsup Required: {\"node\":\">=22\"}", "EBADENGINE", ErrEngine},
+		{"npm install script", "npm ERR! code ELIFECYCLE\nnpm ERR! errno 1\nnpm ERR! sh: 1: node-gyp: not found\nnpm ERR! tool@1.0.0 install: `node-gyp rebuild`\nnpm ERR! Exit status 1", "ELIFECYCLE", nil},
+		{"npm 10 install script", "npm error code 127\nnpm error path /usr/local/lib/node_modules/tool\nnpm error command failed\nnpm error command sh -c node-gyp rebuild\nnpm error sh: 1: node-gyp: not found", "127", nil},
+		// pnpm
+		{"pnpm 404", " ERR_PNPM_FETCH_404  GET https://registry.npmjs.org/nonexistent-pkg-xyz: Not Found - 404\n\nThis error happened while installing a direct dependency of /home/dev/.local/share/pnpm/global/5\n\nnonexistent-pkg-xyz is not in the npm registry, or you have no permission to fetch it.", "ERR_PNPM_FETCH_404", ErrNotFound},
+		{"pnpm missing version", " ERR_PNPM_NO_MATCHING_VERSION  No matching version found for @openai/codex@99.0.0\n\nThis error happened while installing a direct dependency of /home/dev/.local/share/pnpm/global/5", "ERR_PNPM_NO_MATCHING_VERSION", ErrNotFound},
+		{"pnpm offline", " ERR_PNPM_META_FETCH_FAIL  GET https://registry.npmjs.org/@openai%2Fcodex: request to https://registry.npmjs.org/@openai%2Fcodex failed, reason: getaddrinfo ENOTFOUND registry.npmjs.org", "ERR_PNPM_META_FETCH_FAIL", ErrNetwork},
+		{"pnpm permission", " EACCES  EACCES: permission denied, mkdir '/usr/local/pnpm-global/5'", "", ErrPermission},
+		// Yarn classic
+		{"yarn 404", `error An unexpected error occurred: "https://registry.yarnpkg.com/nonexistent-pkg-xyz: Not found".`, "", ErrNotFound},
+		{"yarn missing version", `error Couldn't find any versions for "@openai/codex" that matches "99.0.0"`, "", ErrNotFound},
+		{"yarn permission", `error An unexpected error occurred: "EACCES: permission denied, mkdir '/usr/local/share/.config/yarn/global/node_modules'".`, "", ErrPermission},
+		{"yarn offline", `error An unexpected error occurred: "https://registry.yarnpkg.com/@openai%2fcodex: getaddrinfo ENOTFOUND registry.yarnpkg.com".`, "", ErrNetwork},
+		{"yarn install script", "error /home/dev/.config/yarn/global/node_modules/tool: Command failed.\nExit code: 127\nCommand: node-gyp rebuild\nOutput:\n/bin/sh: 1: node-gyp: not found", "", nil},
+		// Bun
+		{"bun 404", "error: GET https://registry.npmjs.org/nonexistent-pkg-xyz - 404", "", ErrNotFound},
+		{"bun missing version", `error: No version matching "99.0.0" found for specifier "@openai/codex" (but package exists)`, "", ErrNotFound},
+		{"bun offline", "error: ConnectionRefused downloading package manifest @openai/codex", "", ErrNetwork},
+		// pipx
+		{"pipx missing package", "Fatal error from pip prevented installation. Full pip output in file:\n    /home/dev/.local/pipx/logs/cmd_pip_errors.log\n\nSome possibly relevant errors from pip install:\n    ERROR: Could not find a version that satisfies the requirement nonexistent-pkg-xyz (from versions: none)\n    ERROR: No matching distribution found for nonexistent-pkg-xyz\n\nError installing nonexistent-pkg-xyz.", "", ErrNotFound},
+		{"pipx offline", "Some possibly relevant errors from pip install:\n    WARNING: Retrying (Retry(total=4, connect=None, read=None, redirect=None, status=None)) after connection broken by 'NewConnectionError('<pip._vendor.urllib3.connection.HTTPSConnection object at 0x7f2c>: Failed to establish a new connection: [Errno -3] Temporary failure in name resolution')': /simple/aider-chat/\n    ERROR: No matching distribution found for aider-chat", "", ErrNetwork},
+		{"pipx permission", "PermissionError: [Errno 13] Permission denied: '/opt/pipx/venvs/aider-chat'", "", ErrPermission},
+		// cargo
+		{"cargo missing crate", "    Updating crates.io index\nerror: could not find `nonexistent-crate-xyz` in registry `crates-io` with version `*`", "", ErrNotFound},
+		{"cargo offline", "error: failed to query replaced source registry `crates-io`\n\nCaused by:\n  download of config.json failed\n\nCaused by:\n  failed to download from `https://index.crates.io/config.json`\n\nCaused by:\n  [6] Couldn't resolve host name (Could not resolve host: index.crates.io)", "", ErrNetwork},
+		{"cargo permission", "error: failed to create directory `/usr/local/cargo/registry/cache/index.crates.io-6f17d22bba15001f`\n\nCaused by:\n  Permission denied (os error 13)", "", ErrPermission},
+		{"cargo build failure", "error: failed to compile `tool v1.0.0`, intermediate artifacts can be found at `/tmp/cargo-install`.\n\nCaused by:\n  failed to run custom build command for `openssl-sys v0.9.102`\n  sh: 1: pkg-config: not found", "", nil},
+		// go
+		{"go missing module", "go: example.com/nonexistent/cmd@latest: module example.com/nonexistent/cmd: reading https://proxy.golang.org/example.com/nonexistent/cmd/@v/list: 404 Not Found\n\tserver response: not found: module example.com/nonexistent/cmd: no matching versions for query \"latest\"", "", ErrNotFound},
+		{"go missing version", "go: github.com/acme/tool/cmd/tool@v9.9.9: github.com/acme/tool@v9.9.9: invalid version: unknown revision v9.9.9", "", ErrNotFound},
+		{"go offline", "go: github.com/acme/tool/cmd/tool@latest: module github.com/acme/tool/cmd/tool: Get \"https://proxy.golang.org/github.com/acme/tool/cmd/tool/@v/list\": dial tcp: lookup proxy.golang.org on 127.0.0.53:53: no such host", "", ErrNetwork},
+		{"go build failure", "# github.com/acme/tool/cmd/tool\ncmd/tool/main.go:5:2: undefined: missing", "", nil},
+		{"unrecognized", "Segmentation fault (core dumped)", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, kind := classify(tt.stderr)
+			if code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if !errors.Is(kind, tt.wantKind) || (kind == nil) != (tt.wantKind == nil) {
+				t.Errorf("kind = %v, want %v", kind, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestCommandErrorKind(t *testing.T) {
+	err := error(&CommandError{Code: "E404", Stderr: "npm error code E404", Kind: ErrNotFound})
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrNetwork) {
+		t.Errorf("CommandError does not unwrap to its kind only")
+	}
+	if err.Error() != "npm error code E404" {
+		t.Errorf("Error() = %q, want the stderr", err.Error())
+	}
+	if marked := withKind(errors.New("rate limited"), ErrNetwork); !errors.Is(marked, ErrNetwork) || marked.Error() != "rate limited" {
+		t.Errorf("withKind = %v", marked)
+	}
+}
@@ -0,0 +1,307 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// releasesFile is the file in the state directory that records the binaries
+// installed from GitHub releases
+const releasesFile = "releases.json"
+
+// GitHubInstaller installs binaries from the releases of GitHub repositories.
+// Packages are "owner/repo"; versions are release tags without the leading v.
+// Downloads are verified against the SHA256 checksums the release publishes,
+// and releases that publish none are refused. The executables of verified
+// downloads are extracted into BinDir and their versions recorded in StateDir.
+type GitHubInstaller struct {
+	// Client reads the releases; point its APIURL at a local server to use a
+	// stand-in for GitHub
+	Client *github.Client
+	// BinDir is the directory binaries are installed into
+	BinDir string
+	// StateDir is the directory the installed versions are recorded in
+	StateDir string
+	// Bins names the executables installed from the releases of some
+	// repositories; other repositories install the one named after them
+	Bins map[string][]string
+
+	// mu serializes changes to the releases file
+	mu sync.Mutex
+}
+
+// ReleaseRecord is what atm records about the binaries it installed from a
+// repository's release
+type ReleaseRecord struct {
+	Version string `json:"version"`
+	// Asset is the release asset the binaries were extracted from
+	Asset string `json:"asset"`
+	// SHA256 is the verified checksum of the asset
+	SHA256 string   `json:"sha256"`
+	Files  []string `json:"files"`
+}
+
+// NewGitHubInstaller creates an installer that installs into binDir and
+// records installed versions in stateDir
+func NewGitHubInstaller(client *http.Client, binDir, stateDir string) *GitHubInstaller {
+	return &GitHubInstaller{Client: github.NewClient(client), BinDir: binDir, StateDir: stateDir}
+}
+
+// Name returns the source name
+func (g *GitHubInstaller) Name() string {
+	return "github-release"
+}
+
+// Command returns "", since releases are downloaded without other programs
+func (g *GitHubInstaller) Command() string {
+	return ""
+}
+
+// Install downloads the release asset for this platform, verifies its
+// checksum and extracts its executables, replacing the files of an
+// installed version. Executables another repository installed are not
+// overwritten.
+func (g *GitHubInstaller) Install(ctx context.Context, repo, version string) error {
+	release, err := g.release(ctx, repo, version)
+	if err != nil {
+		return err
+	}
+
+	asset, ok := github.PickAsset(release.Assets, runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return withKind(fmt.Errorf("release %s of %s has no asset for %s/%s", release.TagName, repo, runtime.GOOS, runtime.GOARCH), ErrNotFound)
+	}
+	sum, published, err := g.Client.Checksum(ctx, release, asset.Name)
+	if err != nil {
+		return githubError(ctx, err)
+	}
+	if !published {
+		return fmt.Errorf("%s of %s: %w for %s", release.TagName, repo, github.ErrNoChecksum, asset.Name)
+	}
+
+	if err := os.MkdirAll(g.BinDir, 0o755); err != nil {
+		return fileError(err)
+	}
+	download, err := g.download(ctx, asset)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(download)
+
+	if err := github.VerifyFile(download, asset.Name, sum); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	records, err := g.records()
+	if err != nil {
+		return err
+	}
+	bins := g.bins(repo)
+	for _, bin := range bins {
+		if owner := fileOwner(records, repo, binaryName(bin)); owner != "" {
+			return fmt.Errorf("%s in %s was installed from %s", binaryName(bin), g.BinDir, owner)
+		}
+	}
+
+	files, err := extractAsset(download, asset.Name, g.BinDir, bins)
+	if err != nil {
+		return err
+	}
+	// Remove the files of the previous version the new one does not replace
+	for _, old := range records[repo].Files {
+		if !slices.Contains(files, old) && fileOwner(records, repo, old) == "" {
+			os.Remove(filepath.Join(g.BinDir, old))
+		}
+	}
+	records[repo] = ReleaseRecord{Version: release.Version(), Asset: asset.Name, SHA256: sum, Files: files}
+	return g.saveRecords(records)
+}
+
+// Update installs the latest release
+func (g *GitHubInstaller) Update(ctx context.Context, repo string) error {
+	return g.Install(ctx, repo, "")
+}
+
+// Uninstall removes the files installed from a repository's release
+func (g *GitHubInstaller) Uninstall(ctx context.Context, repo string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	records, err := g.records()
+	if err != nil {
+		return err
+	}
+	record, ok := records[repo]
+	if !ok {
+		return withKind(fmt.Errorf("%s was not installed by atm", repo), ErrNotFound)
+	}
+
+	for _, file := range record.Files {
+		if err := os.Remove(filepath.Join(g.BinDir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fileError(err)
+		}
+	}
+	delete(records, repo)
+	return g.saveRecords(records)
+}
+
+// InstalledVersions reads the recorded versions of repositories whose
+// binaries are still present
+func (g *GitHubInstaller) InstalledVersions(ctx context.Context, repos []string) (map[string]string, error) {
+	versions := make(map[string]string)
+	for _, repo := range repos {
+		if record, ok, err := g.Record(repo); err != nil {
+			return nil, err
+		} else if ok {
+			versions[repo] = record.Version
+		}
+	}
+	return versions, nil
+}
+
+// Record returns what atm recorded about the installed release of a
+// repository. ok is false if its binaries are not installed.
+func (g *GitHubInstaller) Record(repo string) (record ReleaseRecord, ok bool, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	records, err := g.records()
+	if err != nil {
+		return ReleaseRecord{}, false, err
+	}
+	record, ok = records[repo]
+	if !ok || len(record.Files) == 0 {
+		return ReleaseRecord{}, false, nil
+	}
+	if _, err := os.Stat(filepath.Join(g.BinDir, record.Files[0])); err != nil {
+		return ReleaseRecord{}, false, nil
+	}
+	return record, true, nil
+}
+
+// LatestVersion returns the version of the latest release
+func (g *GitHubInstaller) LatestVersion(ctx context.Context, repo string) (string, error) {
+	release, err := g.release(ctx, repo, "")
+	if err != nil {
+		return "", err
+	}
+	return release.Version(), nil
+}
+
+// Repository returns the repository the releases are published in
+func (g *GitHubInstaller) Repository(ctx context.Context, repo string) (Repository, error) {
+	return Repository{URL: "https://github.com/" + repo}, nil
+}
+
+// bins returns the names of the executables installed from a repository
+func (g *GitHubInstaller) bins(repo string) []string {
+	if bins := g.Bins[repo]; len(bins) > 0 {
+		return bins
+	}
+	return []string{path.Base(repo)}
+}
+
+// fileOwner returns the repository other than repo whose record lists a file
+// in BinDir, or "" if no other repository installed it
+func fileOwner(records map[string]ReleaseRecord, repo, file string) string {
+	for other, record := range records {
+		if other != repo && slices.Contains(record.Files, file) {
+			return other
+		}
+	}
+	return ""
+}
+
+// release reads the release of a version, or the latest release
+func (g *GitHubInstaller) release(ctx context.Context, repo, version string) (*github.Release, error) {
+	var release *github.Release
+	var err error
+	if version == "" {
+		release, err = g.Client.LatestRelease(ctx, repo)
+	} else {
+		release, err = g.Client.ReleaseByVersion(ctx, repo, version)
+	}
+	return release, githubError(ctx, err)
+}
+
+// download saves a release asset to a temporary file in BinDir and returns its path
+func (g *GitHubInstaller) download(ctx context.Context, asset github.Asset) (string, error) {
+	file, err := os.CreateTemp(g.BinDir, ".download-*")
+	if err != nil {
+		return "", fileError(err)
+	}
+	defer file.Close()
+
+	if err := g.Client.Download(ctx, asset, file); err != nil {
+		os.Remove(file.Name())
+		return "", githubError(ctx, err)
+	}
+	return file.Name(), nil
+}
+
+// records reads the releases file
+func (g *GitHubInstaller) records() (map[string]ReleaseRecord, error) {
+	records := make(map[string]ReleaseRecord)
+	data, err := os.ReadFile(filepath.Join(g.StateDir, releasesFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return records, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", releasesFile, err)
+	}
+	return records, nil
+}
+
+// saveRecords writes the releases file
+func (g *GitHubInstaller) saveRecords(records map[string]ReleaseRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(g.StateDir, 0o755); err != nil {
+		return fileError(err)
+	}
+	return fileError(writeFileAtomic(filepath.Join(g.StateDir, releasesFile), data, 0o644))
+}
+
+// githubError marks a failed GitHub request with the kind of failure: a
+// missing release as ErrNotFound, and a server or connection error or an
+// exceeded rate limit as ErrNetwork
+func githubError(ctx context.Context, err error) error {
+	var status *github.StatusError
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &status):
+		if status.StatusCode == http.StatusNotFound {
+			return withKind(err, ErrNotFound)
+		}
+		if status.StatusCode >= http.StatusInternalServerError || status.RateLimited {
+			return withKind(err, ErrNetwork)
+		}
+		return err
+	case errors.As(err, &urlErr):
+		return networkError(ctx, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,207 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// testRelease is a release served by a stand-in for the GitHub API
+type testRelease struct {
+	tag    string
+	assets map[string][]byte
+}
+
+// newGitHubServer serves the latest release of each repository and its assets
+func newGitHubServer(t *testing.T, releases map[string]testRelease) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := strings.CutPrefix(r.URL.Path, "/download/"); ok {
+			for _, release := range releases {
+				if data, ok := release.assets[name]; ok {
+					w.Write(data)
+					return
+				}
+			}
+			http.NotFound(w, r)
+			return
+		}
+
+		repo, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/repos/"), "/releases/latest")
+		release, found := releases[repo]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		document := github.Release{TagName: release.tag}
+		for name := range release.assets {
+			document.Assets = append(document.Assets, github.Asset{Name: name, URL: server.URL + "/download/" + name})
+		}
+		json.NewEncoder(w).Encode(document)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestInstaller creates an installer that reads releases from server
+func newTestInstaller(t *testing.T, server *httptest.Server) *GitHubInstaller {
+	t.Helper()
+	g := NewGitHubInstaller(server.Client(), t.TempDir(), t.TempDir())
+	g.Client.APIURL = server.URL + "/"
+	return g
+}
+
+// platformAsset returns the name of an asset for this platform
+func platformAsset(project, suffix string) string {
+	return project + "_" + runtime.GOOS + "_" + runtime.GOARCH + suffix
+}
+
+// checksums returns a checksums file listing assets
+func checksums(assets map[string][]byte) []byte {
+	var b strings.Builder
+	for name, data := range assets {
+		sum := sha256.Sum256(data)
+		b.WriteString(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	}
+	return []byte(b.String())
+}
+
+func TestGitHubInstallVerifiesChecksum(t *testing.T) {
+	archive := platformAsset("tool", ".tar.gz")
+	data := tarGz(t, []archiveFile{
+		{"tool/" + binaryName("tool"), 0o755, "tool binary"},
+		{"tool/completions.sh", 0o755, "complete"},
+	})
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		assets  map[string][]byte
+		wantErr error
+	}{
+		{"checksum match", map[string][]byte{archive: data, "checksums.txt": checksums(map[string][]byte{archive: data})}, nil},
+		{"checksum mismatch", map[string][]byte{archive: tampered, "checksums.txt": checksums(map[string][]byte{archive: data})}, github.ErrChecksumMismatch},
+		{"no checksums file", map[string][]byte{archive: data}, github.ErrNoChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newGitHubServer(t, map[string]testRelease{"acme/tool": {"v1.2.0", tt.assets}})
+			g := newTestInstaller(t, server)
+
+			err := g.Install(context.Background(), "acme/tool", "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if files := dirFiles(t, g.BinDir); len(files) != 0 {
+					t.Errorf("BinDir holds %v after a refused install", files)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if files := dirFiles(t, g.BinDir); !reflect.DeepEqual(files, []string{binaryName("tool")}) {
+				t.Errorf("BinDir holds %v, want only the binary", files)
+			}
+			record, ok, err := g.Record("acme/tool")
+			if err != nil || !ok {
+				t.Fatalf("Record = %v, %v", ok, err)
+			}
+			sum := sha256.Sum256(data)
+			if record.Version != "1.2.0" || record.Asset != archive || record.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("record = %+v", record)
+			}
+		})
+	}
+}
+
+func TestGitHubInstallZipWithBins(t *testing.T) {
+	archive := platformAsset("cli", ".zip")
+	data := zipArchive(t, []archiveFile{
+		{"bin/" + binaryName("gh"), 0, "gh binary"},
+		{"share/man/gh.1", 0, "manual"},
+	})
+	server := newGitHubServer(t, map[string]testRelease{"cli/cli": {"v2.0.0", map[string][]byte{
+		archive:             data,
+		archive + ".sha256": checksums(map[string][]byte{archive: data}),
+	}}})
+	g := newTestInstaller(t, server)
+	g.Bins = map[string][]string{"cli/cli": {"gh"}}
+
+	if err := g.Install(context.Background(), "cli/cli", ""); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := g.InstalledVersions(context.Background(), []string{"cli/cli", "acme/other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, map[string]string{"cli/cli": "2.0.0"}) {
+		t.Errorf("InstalledVersions = %v", versions)
+	}
+
+	if err := g.Uninstall(context.Background(), "cli/cli"); err != nil {
+		t.Fatal(err)
+	}
+	if files := dirFiles(t, g.BinDir); len(files) != 0 {
+		t.Errorf("BinDir holds %v after uninstalling", files)
+	}
+}
+
+func TestGitHubInstallKeepsOtherRepositoriesFiles(t *testing.T) {
+	release := func(content string) testRelease {
+		archive := platformAsset(content, ".tar.gz")
+		data := tarGz(t, []archiveFile{{binaryName("tool"), 0o755, content}})
+		return testRelease{"v1.0.0", map[string][]byte{archive: data, content + "_checksums.txt": checksums(map[string][]byte{archive: data})}}
+	}
+	server := newGitHubServer(t, map[string]testRelease{"acme/tool": release("acme"), "other/tool": release("other")})
+	g := newTestInstaller(t, server)
+	ctx := context.Background()
+
+	if err := g.Install(ctx, "acme/tool", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Install(ctx, "other/tool", ""); err == nil || !strings.Contains(err.Error(), "acme/tool") {
+		t.Errorf("installing a second binary of the same name = %v, want a refusal naming acme/tool", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(g.BinDir, binaryName("tool"))); string(data) != "acme" {
+		t.Errorf("the binary of acme/tool was replaced with %q", data)
+	}
+	if _, ok, _ := g.Record("other/tool"); ok {
+		t.Error("the refused install was recorded")
+	}
+}
+
+func TestGitHubRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1893456000")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+	}))
+	defer server.Close()
+	g := newTestInstaller(t, server)
+
+	err := g.Install(context.Background(), "acme/tool", "")
+	if !errors.Is(err, github.ErrRateLimited) || !errors.Is(err, ErrNetwork) {
+		t.Errorf("error = %v, want a rate limit marked as ErrNetwork", err)
+	}
+	if _, err := g.LatestVersion(context.Background(), "acme/tool"); !errors.Is(err, github.ErrRateLimited) {
+		t.Errorf("LatestVersion error = %v, want a rate limit", err)
+	}
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// majorSuffix matches the major version suffix of a module path, such as /v2
+var majorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// GoInstaller installs Go commands with go install. Packages are Go package
+// paths such as github.com/owner/repo/cmd/tool.
+type GoInstaller struct{}
+
+// Name returns the source name
+func (g *GoInstaller) Name() string {
+	return "go"
+}
+
+// Command returns the executable the installer runs
+func (g *GoInstaller) Command() string {
+	return "go"
+}
+
+// Install builds and installs a command at a version, or the latest one
+func (g *GoInstaller) Install(ctx context.Context, packagePath, version string) error {
+	query := "latest"
+	if version != "" {
+		query = "v" + strings.TrimPrefix(version, "v")
+	}
+	_, err := run(ctx, "go", "install", packagePath+"@"+query)
+	return err
+}
+
+// Update installs the latest version of a command
+func (g *GoInstaller) Update(ctx context.Context, packagePath string) error {
+	return g.Install(ctx, packagePath, "")
+}
+
+// Uninstall removes the command's binary; go has no uninstall command
+func (g *GoInstaller) Uninstall(ctx context.Context, packagePath string) error {
+	dir, err := g.binDir(ctx)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(commandBinary(dir, packagePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return withKind(err, ErrNotFound)
+	}
+	return fileError(err)
+}
+
+// InstalledVersions reads the module version built into each command's
+// binary with go version -m
+func (g *GoInstaller) InstalledVersions(ctx context.Context, packagePaths []string) (map[string]string, error) {
+	dir, err := g.binDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string)
+	for _, packagePath := range packagePaths {
+		binary := commandBinary(dir, packagePath)
+		if _, err := os.Stat(binary); err != nil {
+			continue
+		}
+
+		info, err := run(ctx, "go", "version", "-m", binary)
+		if err != nil {
+			continue
+		}
+		if version := buildVersion(info, packagePath); version != "" {
+			versions[packagePath] = version
+		}
+	}
+	return versions, nil
+}
+
+// LatestVersion asks the module proxy for the latest version of the module
+// providing a package, trying each path prefix until one is a module
+func (g *GoInstaller) LatestVersion(ctx context.Context, packagePath string) (string, error) {
+	var lastErr error
+	for module := packagePath; strings.Contains(module, "/"); module = path.Dir(module) {
+		version, err := run(ctx, "go", "list", "-m", "-f", "{{.Version}}", module+"@latest")
+		if err == nil {
+			return strings.TrimPrefix(version, "v"), nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrNpmMissing) {
+			return "", err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = withKind(errors.New("no module provides "+packagePath), ErrNotFound)
+	}
+	return "", lastErr
+}
+
+// Repository returns the repository a package path names, which for
+// packages hosted on GitHub is github.com/owner/repo
+func (g *GoInstaller) Repository(ctx context.Context, packagePath string) (Repository, error) {
+	return Repository{URL: "https://" + packagePath}, nil
+}
+
+// binDir returns the directory go install writes commands to: $GOBIN, or the
+// bin directory of the first GOPATH entry
+func (g *GoInstaller) binDir(ctx context.Context) (string, error) {
+	output, err := run(ctx, "go", "env", "-json", "GOBIN", "GOPATH")
+	if err != nil {
+		return "", err
+	}
+
+	var env struct {
+		GOBIN  string
+		GOPATH string
+	}
+	if err := json.Unmarshal([]byte(output), &env); err != nil {
+		return "", fmt.Errorf("failed to parse go env: %w", err)
+	}
+	if env.GOBIN != "" {
+		return env.GOBIN, nil
+	}
+	return filepath.Join(filepath.SplitList(env.GOPATH)[0], "bin"), nil
+}
+
+// commandBinary returns the path of a command's binary in dir
+func commandBinary(dir, packagePath string) string {
+	name := commandName(packagePath)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(dir, name)
+}
+
+// commandName returns the binary name of a Go package: its last path
+// element, skipping a major version suffix
+func commandName(packagePath string) string {
+	name := path.Base(packagePath)
+	if majorSuffix.MatchString(name) {
+		name = path.Base(path.Dir(packagePath))
+	}
+	return name
+}
+
+// buildVersion reads the main module version from go version -m output, if
+// the binary was built from packagePath
+func buildVersion(info, packagePath string) string {
+	var builtFrom, version string
+	for _, line := range strings.Split(info, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "path" {
+			builtFrom = fields[1]
+		}
+		if len(fields) >= 3 && fields[0] == "mod" {
+			version = fields[2]
+		}
+	}
+	if builtFrom != packagePath || version == "(devel)" {
+		return ""
+	}
+	return strings.TrimPrefix(version, "v")
+}
@@ -0,0 +1,56 @@
+package manager
+
+import "testing"
+
+// goVersionInfo is the output of `go version -m` for a binary built by
+// go install from a module with a major version suffix
+const goVersionInfo = `/home/dev/go/bin/tool: go1.22.1
+	path	github.com/acme/tool/v2/cmd/tool
+	mod	github.com/acme/tool/v2	v2.3.1	h1:Vm3bMSmQ4Bx6Z2Y5eU4pCk1XqUH4l0A0tZ1o1bq5R2k=
+	dep	github.com/spf13/cobra	v1.8.0	h1:7aJaZx1B85qltLMc546zn58BxxfZdR/W22ej9CFoEf0=
+	build	-buildmode=exe
+	build	-compiler=gc
+`
+
+func TestBuildVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        string
+		packagePath string
+		want        string
+	}{
+		{"major version suffix", goVersionInfo, "github.com/acme/tool/v2/cmd/tool", "2.3.1"},
+		{"other package", goVersionInfo, "github.com/acme/tool/cmd/tool", ""},
+		{"module root", "/home/dev/go/bin/gopls: go1.22.1\n\tpath\tgolang.org/x/tools/gopls\n\tmod\tgolang.org/x/tools/gopls\tv0.15.2\th1:abc=\n", "golang.org/x/tools/gopls", "0.15.2"},
+		{"pseudo-version", "/home/dev/go/bin/tool: go1.22.1\n\tpath\texample.com/tool\n\tmod\texample.com/tool\tv0.0.0-20240101000000-abcdef123456\n", "example.com/tool", "0.0.0-20240101000000-abcdef123456"},
+		{"built from a checkout", "/home/dev/go/bin/tool: go1.22.1\n\tpath\texample.com/tool\n\tmod\texample.com/tool\t(devel)\t\n", "example.com/tool", ""},
+		{"not a Go binary", "/usr/local/bin/tool: could not read Go build info from /usr/local/bin/tool", "example.com/tool", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildVersion(tt.info, tt.packagePath); got != tt.want {
+				t.Errorf("buildVersion = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		packagePath string
+		want        string
+	}{
+		{"github.com/acme/tool", "tool"},
+		{"github.com/acme/tool/cmd/tool-cli", "tool-cli"},
+		{"github.com/acme/tool/v2", "tool"},
+		{"github.com/acme/tool/v12", "tool"},
+		{"github.com/acme/tool/v2/cmd/tool", "tool"},
+		{"github.com/acme/vim", "vim"},
+		{"github.com/acme/v2ray", "v2ray"},
+	}
+	for _, tt := range tests {
+		if got := commandName(tt.packagePath); got != tt.want {
+			t.Errorf("commandName(%q) = %q, want %q", tt.packagePath, got, tt.want)
+		}
+	}
+}
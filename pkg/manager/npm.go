@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+)
+
+// NpmBackend manages global packages with npm
+type NpmBackend struct {
+	store globalStore
+}
+
+// Name returns the package manager executable name
+func (b *NpmBackend) Name() string {
+	return "npm"
+}
+
+// Install installs a package globally
+func (b *NpmBackend) Install(ctx context.Context, packageSpec string) error {
+	_, err := run(ctx, "npm", "install", "-g", packageSpec)
+	return err
+}
+
+// Update updates a package to the latest version
+func (b *NpmBackend) Update(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "npm", "update", "-g", packageName)
+	return err
+}
+
+// Uninstall removes a global package
+func (b *NpmBackend) Uninstall(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "npm", "uninstall", "-g", packageName)
+	return err
+}
+
+// InstalledVersion returns the version installed in the global store
+func (b *NpmBackend) InstalledVersion(ctx context.Context, packageName string) (string, error) {
+	return b.store.installedVersion(ctx, b.globalRoot, packageName)
+}
+
+// InstalledPackages lists the global store
+func (b *NpmBackend) InstalledPackages(ctx context.Context) (map[string]string, error) {
+	return b.store.installedPackages(ctx, b.globalRoot)
+}
+
+// GlobalRoot returns the global node_modules directory
+func (b *NpmBackend) GlobalRoot(ctx context.Context) (string, error) {
+	return b.store.resolve(ctx, b.globalRoot)
+}
+
+// GlobalBin returns the directory global executables are linked into
+// (`npm prefix -g`, plus bin outside Windows)
+func (b *NpmBackend) GlobalBin(ctx context.Context) (string, error) {
+	prefix, err := run(ctx, "npm", "prefix", "-g")
+	if err != nil {
+		return "", err
+	}
+	return PrefixBin(prefix), nil
+}
+
+// PrefixBin returns the directory npm links executables into for a prefix
+func PrefixBin(prefix string) string {
+	if runtime.GOOS == "windows" {
+		return prefix
+	}
+	return filepath.Join(prefix, "bin")
+}
+
+// SetPrefix makes npm install global packages under dir by writing the
+// prefix setting to the user's .npmrc
+func (b *NpmBackend) SetPrefix(ctx context.Context, dir string) error {
+	if _, err := run(ctx, "npm", "config", "set", "prefix", dir); err != nil {
+		return err
+	}
+	// The global directory moved; resolve it again on next use
+	b.store = globalStore{}
+	return nil
+}
+
+// globalRoot returns the global node_modules directory (`npm root -g`)
+func (b *NpmBackend) globalRoot(ctx context.Context) (string, error) {
+	return run(ctx, "npm", "root", "-g")
+}
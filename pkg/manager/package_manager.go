@@ -1,137 +1,326 @@
 package manager
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
 )
 
-// PackageManager handles npm package operations
-type PackageManager struct{}
+// DefaultTimeout bounds each install, update or uninstall unless SetTimeouts
+// changes it
+const DefaultTimeout = 10 * time.Minute
+
+// PackageManager handles global package operations through a Backend
+// and reads package metadata from the npm registry. Packages from other
+// sources are handled by an Installer.
+type PackageManager struct {
+	backend  Backend
+	registry *RegistryClient
+	// timeout bounds each install, update or uninstall; zero means no limit
+	timeout time.Duration
+
+	binDir, stateDir string
+	// releaseBins names the executables installed from the releases of
+	// some repositories
+	releaseBins map[string][]string
+	// github reads GitHub releases; nil means the public API
+	github       *github.Client
+	installersMu sync.Mutex
+	installers   map[string]Installer
+}
 
 // NewPackageManager creates a new PackageManager instance
-func NewPackageManager() *PackageManager {
-	return &PackageManager{}
+func NewPackageManager(backend Backend) *PackageManager {
+	return &PackageManager{
+		backend:  backend,
+		registry: NewRegistryClient(),
+		timeout:  DefaultTimeout,
+
+		installers: make(map[string]Installer),
+	}
 }
 
-// NpmListOutput represents the output of npm list command
-type NpmListOutput struct {
-	Dependencies map[string]struct {
-		Version string `json:"version"`
-	} `json:"dependencies"`
+// SetReleaseDirs sets where binaries downloaded from GitHub releases are
+// installed and where their versions are recorded
+func (pm *PackageManager) SetReleaseDirs(binDir, stateDir string) {
+	pm.binDir, pm.stateDir = binDir, stateDir
 }
 
-// IsPackageInstalled checks if a package is installed globally
-func (pm *PackageManager) IsPackageInstalled(packageName string) (bool, error) {
-	cleanName := pm.extractPackageName(packageName)
-	cmd := exec.Command("npm", "list", "-g", cleanName, "--depth=0", "--json")
+// SetReleaseBins sets the executables installed from the releases of
+// repositories, by "owner/repo". Other repositories install the executable
+// named after them.
+func (pm *PackageManager) SetReleaseBins(bins map[string][]string) {
+	pm.releaseBins = bins
+}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
+// SetGitHubClient sets the client GitHub releases are read with, e.g. one
+// for GitHub Enterprise or with a token
+func (pm *PackageManager) SetGitHubClient(client *github.Client) {
+	pm.github = client
+}
 
-	// npm list returns non-zero exit code if package not found, but that's ok
-	_ = cmd.Run()
+// SetTimeouts sets how long an install, update or uninstall and a single
+// registry request may take. Zero means no limit.
+func (pm *PackageManager) SetTimeouts(operation, registry time.Duration) {
+	pm.timeout = operation
+	pm.registry.HTTPClient.Timeout = registry
+}
 
-	var result NpmListOutput
-	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
-		// If JSON parsing fails, package is not installed
-		return false, nil
-	}
+// Backend returns the backend used for package operations
+func (pm *PackageManager) Backend() Backend {
+	return pm.backend
+}
 
-	_, exists := result.Dependencies[cleanName]
-	return exists, nil
+// Registry returns the registry client used for version lookups
+func (pm *PackageManager) Registry() *RegistryClient {
+	return pm.registry
+}
+
+// IsPackageInstalled checks if a package is installed globally
+func (pm *PackageManager) IsPackageInstalled(ctx context.Context, packageName string) (bool, error) {
+	// A missing package is reported as an error by the backend, but that's ok
+	version, _ := pm.backend.InstalledVersion(ctx, extractPackageName(packageName))
+	return version != "", nil
 }
 
 // GetPackageVersion gets the currently installed version of a package
-func (pm *PackageManager) GetPackageVersion(packageName string) (string, error) {
-	cleanName := pm.extractPackageName(packageName)
-	cmd := exec.Command("npm", "list", "-g", cleanName, "--depth=0", "--json")
+func (pm *PackageManager) GetPackageVersion(ctx context.Context, packageName string) (string, error) {
+	return pm.backend.InstalledVersion(ctx, extractPackageName(packageName))
+}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
+// GetInstalledPackages lists every globally installed package with its version in one pass
+func (pm *PackageManager) GetInstalledPackages(ctx context.Context) (map[string]string, error) {
+	return pm.backend.InstalledPackages(ctx)
+}
 
-	_ = cmd.Run()
+// GetLatestVersion gets the latest available version from npm registry
+func (pm *PackageManager) GetLatestVersion(ctx context.Context, packageName string) (string, error) {
+	version, err := pm.registry.LatestVersion(ctx, extractPackageName(packageName))
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest version: %w", err)
+	}
+	return version, nil
+}
 
-	var result NpmListOutput
-	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
-		return "", fmt.Errorf("failed to parse npm output")
+// CheckLatestVersion gets the newest version allowed by constraint (empty for
+// the latest release) with a conditional request, so an unchanged packument
+// identified by etag is not downloaded again
+func (pm *PackageManager) CheckLatestVersion(ctx context.Context, packageName, constraint, etag string) (*LatestResult, error) {
+	result, err := pm.registry.LatestVersionIfChanged(ctx, extractPackageName(packageName), constraint, etag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest version: %w", err)
 	}
+	return result, nil
+}
 
-	if dep, exists := result.Dependencies[cleanName]; exists {
-		return dep.Version, nil
+// ResolveVersion gets the exact version a constraint selects from the registry
+func (pm *PackageManager) ResolveVersion(ctx context.Context, packageName, constraint string) (string, error) {
+	packument, err := pm.GetPackument(ctx, packageName)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve version: %w", err)
 	}
+	return packument.ResolveVersion(constraint)
+}
 
-	return "", fmt.Errorf("package not found")
+// GetPackument gets the registry metadata of every published version of a package
+func (pm *PackageManager) GetPackument(ctx context.Context, packageName string) (*Packument, error) {
+	return pm.registry.Packument(ctx, extractPackageName(packageName))
 }
 
-// GetLatestVersion gets the latest available version from npm registry
-func (pm *PackageManager) GetLatestVersion(packageName string) (string, error) {
-	cleanName := pm.extractPackageName(packageName)
-	cmd := exec.Command("npm", "view", cleanName, "version")
+// GetFullPackument gets the registry metadata of a package including publish times
+func (pm *PackageManager) GetFullPackument(ctx context.Context, packageName string) (*Packument, error) {
+	return pm.registry.FullPackument(ctx, extractPackageName(packageName))
+}
 
-	var out bytes.Buffer
-	var errOut bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errOut
+// InstallPackage installs a package globally
+func (pm *PackageManager) InstallPackage(ctx context.Context, packageName string) error {
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to get latest version: %s", errOut.String())
+	if err := pm.backend.Install(ctx, packageName); err != nil {
+		return pm.operationError("installation", err)
 	}
+	return nil
+}
 
-	version := strings.TrimSpace(out.String())
-	return version, nil
+// UpdatePackage updates a package to the latest version
+func (pm *PackageManager) UpdatePackage(ctx context.Context, packageName string) error {
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
+
+	if err := pm.backend.Update(ctx, extractPackageName(packageName)); err != nil {
+		return pm.operationError("update", err)
+	}
+	return nil
 }
 
-// InstallPackage installs a package globally
-func (pm *PackageManager) InstallPackage(packageName string) error {
-	cmd := exec.Command("npm", "install", "-g", packageName)
+// UninstallPackage removes a package from the system
+func (pm *PackageManager) UninstallPackage(ctx context.Context, packageName string) error {
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
+
+	if err := pm.backend.Uninstall(ctx, extractPackageName(packageName)); err != nil {
+		return pm.operationError("uninstallation", err)
+	}
+	return nil
+}
 
-	var errOut bytes.Buffer
-	cmd.Stderr = &errOut
+// Installer returns the installer for a package source other than npm
+func (pm *PackageManager) Installer(source string) (Installer, error) {
+	pm.installersMu.Lock()
+	defer pm.installersMu.Unlock()
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("installation failed: %s", errOut.String())
+	if installer, ok := pm.installers[source]; ok {
+		return installer, nil
 	}
+	installer, err := NewInstaller(source, InstallerOptions{
+		HTTPClient:  pm.registry.HTTPClient,
+		BinDir:      pm.binDir,
+		StateDir:    pm.stateDir,
+		ReleaseBins: pm.releaseBins,
+		GitHub:      pm.github,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pm.installers[source] = installer
+	return installer, nil
+}
+
+// Repository returns the source repository of a package at a version, from
+// its manifest in the registry or from the index of its source. The URL is
+// empty if the package names none.
+func (pm *PackageManager) Repository(ctx context.Context, source, packageName, version string) (Repository, error) {
+	if source == "" || source == "npm" {
+		manifest, err := pm.registry.Manifest(ctx, packageName, version)
+		if err != nil {
+			return Repository{}, err
+		}
+		return manifest.Repository, nil
+	}
+
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return Repository{}, err
+	}
+	finder, ok := installer.(RepositoryFinder)
+	if !ok {
+		return Repository{}, nil
+	}
+	return finder.Repository(ctx, packageName)
+}
 
+// InstallFrom installs a package from a source other than npm at an exact
+// version, or the latest if version is empty
+func (pm *PackageManager) InstallFrom(ctx context.Context, source, packageName, version string) error {
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
+
+	if err := installer.Install(ctx, packageName, version); err != nil {
+		return pm.operationError("installation", err)
+	}
 	return nil
 }
 
-// UpdatePackage updates a package to the latest version
-func (pm *PackageManager) UpdatePackage(packageName string) error {
-	cmd := exec.Command("npm", "update", "-g", packageName)
+// UpdateFrom updates a package from a source other than npm to its latest version
+func (pm *PackageManager) UpdateFrom(ctx context.Context, source, packageName string) error {
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
 
-	var errOut bytes.Buffer
-	cmd.Stderr = &errOut
+	if err := installer.Update(ctx, packageName); err != nil {
+		return pm.operationError("update", err)
+	}
+	return nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("update failed: %s", errOut.String())
+// UninstallFrom removes a package installed from a source other than npm
+func (pm *PackageManager) UninstallFrom(ctx context.Context, source, packageName string) error {
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return err
 	}
 
+	ctx, cancel := pm.withTimeout(ctx)
+	defer cancel()
+
+	if err := installer.Uninstall(ctx, packageName); err != nil {
+		return pm.operationError("uninstallation", err)
+	}
 	return nil
 }
 
-// UninstallPackage removes a package from the system
-func (pm *PackageManager) UninstallPackage(packageName string) error {
-	cleanName := pm.extractPackageName(packageName)
-	cmd := exec.Command("npm", "uninstall", "-g", cleanName)
+// GetVersionFrom gets the installed version of a package from a source other
+// than npm, failing with ErrNotFound if it is not installed
+func (pm *PackageManager) GetVersionFrom(ctx context.Context, source, packageName string) (string, error) {
+	versions, err := pm.GetVersionsFrom(ctx, source, []string{packageName})
+	if err != nil {
+		return "", err
+	}
+	version, ok := versions[packageName]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return version, nil
+}
+
+// GetVersionsFrom gets the installed version of each of the packages from a
+// source other than npm that is installed
+func (pm *PackageManager) GetVersionsFrom(ctx context.Context, source string, packageNames []string) (map[string]string, error) {
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return nil, err
+	}
+	return installer.InstalledVersions(ctx, packageNames)
+}
 
-	var errOut bytes.Buffer
-	cmd.Stderr = &errOut
+// GetLatestVersionFrom gets the latest version of a package from the index
+// of a source other than npm
+func (pm *PackageManager) GetLatestVersionFrom(ctx context.Context, source, packageName string) (string, error) {
+	installer, err := pm.Installer(source)
+	if err != nil {
+		return "", err
+	}
+	version, err := installer.LatestVersion(ctx, packageName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest version: %w", err)
+	}
+	return version, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("uninstallation failed: %s", errOut.String())
+// withTimeout bounds a package operation by the configured timeout
+func (pm *PackageManager) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if pm.timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, pm.timeout)
+}
 
-	return nil
+// operationError describes a failed package operation
+func (pm *PackageManager) operationError(operation string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s: %w", operation, pm.timeout, err)
+	}
+	return fmt.Errorf("%s failed: %w", operation, err)
 }
 
 // extractPackageName extracts the package name from a package string that may include version
 // Handles scoped packages like @scope/package and @scope/package@version
-func (pm *PackageManager) extractPackageName(packageWithVersion string) string {
+func extractPackageName(packageWithVersion string) string {
 	// Handle scoped packages (starting with @)
 	if strings.HasPrefix(packageWithVersion, "@") {
 		parts := strings.Split(packageWithVersion, "@")
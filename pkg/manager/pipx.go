@@ -0,0 +1,146 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// PyPIURL is the JSON API of the Python Package Index
+const PyPIURL = "https://pypi.org/pypi/"
+
+// PipxInstaller installs Python applications from PyPI into isolated
+// environments with pipx
+type PipxInstaller struct {
+	client *http.Client
+}
+
+// Name returns the source name
+func (p *PipxInstaller) Name() string {
+	return "pipx"
+}
+
+// Command returns the executable the installer runs
+func (p *PipxInstaller) Command() string {
+	return "pipx"
+}
+
+// Install installs a package, replacing an installed version
+func (p *PipxInstaller) Install(ctx context.Context, packageName, version string) error {
+	if version == "" {
+		_, err := run(ctx, "pipx", "install", packageName)
+		return err
+	}
+	_, err := run(ctx, "pipx", "install", "--force", packageName+"=="+version)
+	return err
+}
+
+// Update upgrades a package to its latest version
+func (p *PipxInstaller) Update(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "pipx", "upgrade", pythonName(packageName))
+	return err
+}
+
+// Uninstall removes a package and its environment
+func (p *PipxInstaller) Uninstall(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "pipx", "uninstall", pythonName(packageName))
+	return err
+}
+
+// InstalledVersions reads the packages installed by pipx from `pipx list --json`
+func (p *PipxInstaller) InstalledVersions(ctx context.Context, packageNames []string) (map[string]string, error) {
+	output, err := run(ctx, "pipx", "list", "--json")
+	if err != nil {
+		return nil, err
+	}
+	return pipxVersions(output, packageNames)
+}
+
+// pipxVersions reads the versions of packageNames from the output of
+// `pipx list --json`, matching names as PyPI does
+func pipxVersions(output string, packageNames []string) (map[string]string, error) {
+	var list struct {
+		Venvs map[string]struct {
+			Metadata struct {
+				MainPackage struct {
+					Package        string `json:"package"`
+					PackageVersion string `json:"package_version"`
+				} `json:"main_package"`
+			} `json:"metadata"`
+		} `json:"venvs"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse pipx list: %w", err)
+	}
+
+	installed := make(map[string]string, len(list.Venvs))
+	for _, venv := range list.Venvs {
+		main := venv.Metadata.MainPackage
+		installed[normalizePythonName(main.Package)] = main.PackageVersion
+	}
+
+	versions := make(map[string]string)
+	for _, name := range packageNames {
+		if version, ok := installed[normalizePythonName(pythonName(name))]; ok {
+			versions[name] = version
+		}
+	}
+	return versions, nil
+}
+
+// LatestVersion reads the latest release of a package from PyPI
+func (p *PipxInstaller) LatestVersion(ctx context.Context, packageName string) (string, error) {
+	var project struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := getJSON(ctx, p.client, PyPIURL+url.PathEscape(pythonName(packageName))+"/json", &project); err != nil {
+		return "", err
+	}
+	return project.Info.Version, nil
+}
+
+// Repository reads the repository of a package from the project URLs on
+// PyPI, preferring the links labeled as the source code
+func (p *PipxInstaller) Repository(ctx context.Context, packageName string) (Repository, error) {
+	var project struct {
+		Info struct {
+			HomePage    string            `json:"home_page"`
+			ProjectURLs map[string]string `json:"project_urls"`
+		} `json:"info"`
+	}
+	if err := getJSON(ctx, p.client, PyPIURL+url.PathEscape(pythonName(packageName))+"/json", &project); err != nil {
+		return Repository{}, err
+	}
+
+	for _, label := range []string{"Source", "Source Code", "Repository", "Code", "GitHub", "Homepage"} {
+		for key, link := range project.Info.ProjectURLs {
+			if strings.EqualFold(key, label) && link != "" {
+				return Repository{URL: link}, nil
+			}
+		}
+	}
+	return Repository{URL: project.Info.HomePage}, nil
+}
+
+// pythonName strips extras such as [all] from a Python requirement
+func pythonName(requirement string) string {
+	if i := strings.IndexByte(requirement, '['); i >= 0 {
+		return requirement[:i]
+	}
+	return requirement
+}
+
+// pythonSeparators matches the separators PEP 503 treats as equal
+var pythonSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName returns the PEP 503 normalized form of a package name,
+// under which Aider_Chat and aider-chat are the same package
+func normalizePythonName(name string) string {
+	return pythonSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pipxList is the output of `pipx list --json` (pipx 1.4), shortened to the
+// fields atm reads and one injected package
+const pipxList = `{
+  "pipx_spec_version": "0.1",
+  "venvs": {
+    "aider-chat": {
+      "metadata": {
+        "injected_packages": {
+          "boto3": {"package": "boto3", "package_version": "1.34.0"}
+        },
+        "main_package": {
+          "app_paths": [{"__Path__": "/home/dev/.local/pipx/venvs/aider-chat/bin/aider", "__type__": "Path"}],
+          "apps": ["aider"],
+          "package": "aider-chat",
+          "package_or_url": "aider-chat==0.86.1",
+          "package_version": "0.86.1",
+          "pip_args": [],
+          "suffix": ""
+        },
+        "python_version": "Python 3.12.3"
+      }
+    },
+    "open_interpreter": {
+      "metadata": {
+        "injected_packages": {},
+        "main_package": {
+          "apps": ["interpreter"],
+          "package": "open_interpreter",
+          "package_or_url": "open-interpreter",
+          "package_version": "0.4.3"
+        }
+      }
+    }
+  }
+}`
+
+func TestPipxVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		packages []string
+		want     map[string]string
+	}{
+		{"installed", pipxList, []string{"aider-chat"}, map[string]string{"aider-chat": "0.86.1"}},
+		{"normalized names", pipxList, []string{"Open.Interpreter", "AIDER_CHAT"}, map[string]string{"Open.Interpreter": "0.4.3", "AIDER_CHAT": "0.86.1"}},
+		{"extras", pipxList, []string{"aider-chat[playwright]"}, map[string]string{"aider-chat[playwright]": "0.86.1"}},
+		{"injected packages are not installed", pipxList, []string{"boto3"}, map[string]string{}},
+		{"nothing installed", `{"pipx_spec_version": "0.1", "venvs": {}}`, []string{"aider-chat"}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pipxVersions(tt.output, tt.packages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pipxVersions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := pipxVersions("pipx: command output is not JSON", nil); err == nil {
+		t.Error("pipxVersions accepted output that is not JSON")
+	}
+}
@@ -0,0 +1,57 @@
+package manager
+
+import "context"
+
+// PnpmBackend manages global packages with pnpm
+type PnpmBackend struct {
+	store globalStore
+}
+
+// Name returns the package manager executable name
+func (b *PnpmBackend) Name() string {
+	return "pnpm"
+}
+
+// Install installs a package globally
+func (b *PnpmBackend) Install(ctx context.Context, packageSpec string) error {
+	_, err := run(ctx, "pnpm", "add", "-g", packageSpec)
+	return err
+}
+
+// Update updates a package to the latest version.
+// `pnpm update -g` stays within the saved range, so the latest tag is added instead.
+func (b *PnpmBackend) Update(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "pnpm", "add", "-g", packageName+"@latest")
+	return err
+}
+
+// Uninstall removes a global package
+func (b *PnpmBackend) Uninstall(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "pnpm", "remove", "-g", packageName)
+	return err
+}
+
+// InstalledVersion returns the version installed in the global store
+func (b *PnpmBackend) InstalledVersion(ctx context.Context, packageName string) (string, error) {
+	return b.store.installedVersion(ctx, b.globalRoot, packageName)
+}
+
+// InstalledPackages lists the global store
+func (b *PnpmBackend) InstalledPackages(ctx context.Context) (map[string]string, error) {
+	return b.store.installedPackages(ctx, b.globalRoot)
+}
+
+// GlobalRoot returns the global node_modules directory
+func (b *PnpmBackend) GlobalRoot(ctx context.Context) (string, error) {
+	return b.store.resolve(ctx, b.globalRoot)
+}
+
+// GlobalBin returns the directory global executables are linked into (`pnpm bin -g`)
+func (b *PnpmBackend) GlobalBin(ctx context.Context) (string, error) {
+	return run(ctx, "pnpm", "bin", "-g")
+}
+
+// globalRoot returns the global node_modules directory (`pnpm root -g`)
+func (b *PnpmBackend) globalRoot(ctx context.Context) (string, error) {
+	return run(ctx, "pnpm", "root", "-g")
+}
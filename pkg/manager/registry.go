@@ -0,0 +1,465 @@
+package manager
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/semver"
+)
+
+// DefaultRegistry is the public npm registry
+const DefaultRegistry = "https://registry.npmjs.org/"
+
+// DefaultRegistryTimeout bounds each registry request unless changed
+const DefaultRegistryTimeout = 15 * time.Second
+
+// abbreviatedAccept requests the abbreviated ("corgi") packument format
+const abbreviatedAccept = "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8, */*"
+
+// RegistryClient reads package metadata from an npm-compatible registry
+type RegistryClient struct {
+	// Registry is the default registry URL
+	Registry string
+	// ScopeRegistries maps a scope ("@myorg") to its registry URL
+	ScopeRegistries map[string]string
+	// Credentials maps a nerf-darted registry prefix ("//host/path/") to its auth settings
+	Credentials map[string]Credential
+	// HTTPClient performs the requests; its Timeout bounds each request
+	HTTPClient *http.Client
+	// Proxy and HTTPSProxy are npm's proxy settings. They are reported by
+	// atm doctor; requests made by atm itself use the HTTP(S)_PROXY variables.
+	Proxy      string
+	HTTPSProxy string
+}
+
+// Credential holds the auth settings for one registry
+type Credential struct {
+	Token string // _authToken, sent as a Bearer token
+	Auth  string // _auth, base64 "user:password" sent as Basic auth
+}
+
+// Packument is the registry document describing every version of a package
+type Packument struct {
+	Name     string                    `json:"name"`
+	DistTags map[string]string         `json:"dist-tags"`
+	Versions map[string]PackageVersion `json:"versions"`
+	// Time maps versions to publish times. Only full packuments include it.
+	Time     PublishTimes `json:"time"`
+	Modified string       `json:"modified"`
+}
+
+// PublishTimes maps versions (plus "created" and "modified") to RFC 3339 timestamps
+type PublishTimes map[string]string
+
+// UnmarshalJSON decodes the time object, skipping non-string entries such as
+// the "unpublished" record of packages that were removed
+func (t *PublishTimes) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*t = make(PublishTimes, len(raw))
+	for key, value := range raw {
+		var timestamp string
+		if json.Unmarshal(value, &timestamp) == nil {
+			(*t)[key] = timestamp
+		}
+	}
+	return nil
+}
+
+// LatestResult is the outcome of a conditional latest-version lookup
+type LatestResult struct {
+	Version string
+	ETag    string
+	// NotModified is set when the registry confirmed the caller's ETag is
+	// still current; Version is then empty
+	NotModified bool
+}
+
+// PublishTime returns when a version was published, or the zero time if unknown
+func (p *Packument) PublishTime(version string) time.Time {
+	published, _ := time.Parse(time.RFC3339, p.Time[version])
+	return published
+}
+
+// ResolveVersion returns the version a constraint selects: the version of a
+// dist-tag, or the highest published version in a range. An empty constraint
+// selects the latest tag.
+func (p *Packument) ResolveVersion(constraint string) (string, error) {
+	if constraint == "" {
+		constraint = "latest"
+	}
+
+	if versionRange, err := semver.ParseConstraint(constraint); err == nil {
+		versions := make([]string, 0, len(p.Versions))
+		for version := range p.Versions {
+			versions = append(versions, version)
+		}
+		if version := versionRange.MaxSatisfying(versions); version != "" {
+			return version, nil
+		}
+		return "", withKind(fmt.Errorf("no version of %s matches %s", p.Name, constraint), ErrNotFound)
+	}
+
+	if version := p.DistTags[constraint]; version != "" {
+		return version, nil
+	}
+	return "", withKind(fmt.Errorf("package %s has no %s tag", p.Name, constraint), ErrNotFound)
+}
+
+// PackageVersion is the metadata of a single published version
+type PackageVersion struct {
+	Version    string            `json:"version"`
+	Deprecated string            `json:"deprecated"`
+	Engines    map[string]string `json:"engines"`
+	Dist       struct {
+		Integrity string `json:"integrity"`
+		Shasum    string `json:"shasum"`
+		Tarball   string `json:"tarball"`
+	} `json:"dist"`
+	// Repository is only included in full packuments and version manifests
+	Repository Repository `json:"repository"`
+}
+
+// Repository is the source repository of a package
+type Repository struct {
+	URL string `json:"url"`
+	// Directory is the directory of the package in a monorepo
+	Directory string `json:"directory"`
+}
+
+// UnmarshalJSON decodes the repository field of a manifest, which is either
+// an object or a URL or shorthand such as github:owner/repo
+func (r *Repository) UnmarshalJSON(data []byte) error {
+	var shorthand string
+	if json.Unmarshal(data, &shorthand) == nil {
+		*r = Repository{URL: shorthand}
+		return nil
+	}
+	type object Repository
+	return json.Unmarshal(data, (*object)(r))
+}
+
+// GitHub returns the "owner/repo" of a repository hosted on GitHub
+func (r Repository) GitHub() (string, bool) {
+	repositoryURL := strings.TrimPrefix(r.URL, "github:")
+	// npm reads a bare owner/repo as a GitHub repository
+	if !strings.Contains(repositoryURL, ":") && strings.Count(repositoryURL, "/") == 1 {
+		return strings.TrimSuffix(repositoryURL, ".git"), true
+	}
+	return github.ParseRepository(repositoryURL)
+}
+
+// NewRegistryClient creates a registry client configured from the user's .npmrc.
+// The npm_config_registry environment variable overrides the default registry.
+func NewRegistryClient() *RegistryClient {
+	client := &RegistryClient{
+		Registry:        DefaultRegistry,
+		ScopeRegistries: make(map[string]string),
+		Credentials:     make(map[string]Credential),
+		HTTPClient:      &http.Client{Timeout: DefaultRegistryTimeout},
+	}
+
+	if path := userNpmrcPath(); path != "" {
+		// A missing or unreadable .npmrc just means defaults
+		_ = client.LoadNpmrc(path)
+	}
+
+	for _, key := range []string{"npm_config_registry", "NPM_CONFIG_REGISTRY"} {
+		if registry := os.Getenv(key); registry != "" {
+			client.Registry = registry
+			break
+		}
+	}
+	for key, setting := range map[string]*string{
+		"npm_config_proxy":       &client.Proxy,
+		"npm_config_https_proxy": &client.HTTPSProxy,
+	} {
+		for _, name := range []string{key, strings.ToUpper(key)} {
+			if value := os.Getenv(name); value != "" {
+				*setting = value
+				break
+			}
+		}
+	}
+
+	return client
+}
+
+// userNpmrcPath returns the location of the user's .npmrc
+func userNpmrcPath() string {
+	for _, key := range []string{"npm_config_userconfig", "NPM_CONFIG_USERCONFIG"} {
+		if path := os.Getenv(key); path != "" {
+			return path
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".npmrc")
+}
+
+// envPattern matches ${VAR} references in .npmrc values
+var envPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// LoadNpmrc reads registry, scoped registry, proxy and auth settings from an .npmrc file
+func (c *RegistryClient) LoadNpmrc(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		value = envPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			return os.Getenv(envPattern.FindStringSubmatch(ref)[1])
+		})
+
+		switch {
+		case key == "registry":
+			c.Registry = value
+		case key == "proxy":
+			c.Proxy = value
+		case key == "https-proxy":
+			c.HTTPSProxy = value
+		case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+			c.ScopeRegistries[strings.TrimSuffix(key, ":registry")] = value
+		case strings.HasPrefix(key, "//"):
+			prefix, setting, found := strings.Cut(key, ":_")
+			if !found {
+				continue
+			}
+			credential := c.Credentials[prefix]
+			switch setting {
+			case "authToken":
+				credential.Token = value
+			case "auth":
+				credential.Auth = value
+			default:
+				continue
+			}
+			c.Credentials[prefix] = credential
+		}
+	}
+
+	return scanner.Err()
+}
+
+// RegistryFor returns the registry URL that serves a package
+func (c *RegistryClient) RegistryFor(packageName string) string {
+	if strings.HasPrefix(packageName, "@") {
+		scope, _, _ := strings.Cut(packageName, "/")
+		if registry, ok := c.ScopeRegistries[scope]; ok {
+			return registry
+		}
+	}
+	return c.Registry
+}
+
+// Packument fetches the abbreviated packument of a package: dist-tags and
+// per-version install metadata, without publish times
+func (c *RegistryClient) Packument(ctx context.Context, packageName string) (*Packument, error) {
+	packument, _, err := c.fetch(ctx, packageName, abbreviatedAccept, "")
+	return packument, err
+}
+
+// FullPackument fetches the complete packument, including publish times
+func (c *RegistryClient) FullPackument(ctx context.Context, packageName string) (*Packument, error) {
+	packument, _, err := c.fetch(ctx, packageName, "application/json", "")
+	return packument, err
+}
+
+// Manifest fetches the manifest of a single version of a package
+func (c *RegistryClient) Manifest(ctx context.Context, packageName, version string) (*PackageVersion, error) {
+	registry := c.RegistryFor(packageName)
+	if !strings.HasSuffix(registry, "/") {
+		registry += "/"
+	}
+
+	endpoint := registry + url.PathEscape(packageName) + "/" + url.PathEscape(version)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req, registry)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, networkError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, endpoint); err != nil {
+		return nil, err
+	}
+	var manifest PackageVersion
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s@%s: %w", packageName, version, err)
+	}
+	return &manifest, nil
+}
+
+// LatestVersion returns the version tagged "latest"
+func (c *RegistryClient) LatestVersion(ctx context.Context, packageName string) (string, error) {
+	packument, err := c.Packument(ctx, packageName)
+	if err != nil {
+		return "", err
+	}
+
+	latest := packument.DistTags["latest"]
+	if latest == "" {
+		return "", withKind(fmt.Errorf("package %s has no latest tag", packageName), ErrNotFound)
+	}
+	return latest, nil
+}
+
+// LatestVersionIfChanged looks up the newest version allowed by constraint (see
+// Packument.ResolveVersion) with a conditional request. If etag is still
+// current the registry skips the body and NotModified is set.
+func (c *RegistryClient) LatestVersionIfChanged(ctx context.Context, packageName, constraint, etag string) (*LatestResult, error) {
+	packument, newETag, err := c.fetch(ctx, packageName, abbreviatedAccept, etag)
+	if err != nil {
+		return nil, err
+	}
+	if packument == nil {
+		return &LatestResult{ETag: etag, NotModified: true}, nil
+	}
+
+	latest, err := packument.ResolveVersion(constraint)
+	if err != nil {
+		return nil, err
+	}
+	return &LatestResult{Version: latest, ETag: newETag}, nil
+}
+
+// Ping checks that the default registry answers and returns the HTTP status
+// of its ping endpoint
+func (c *RegistryClient) Ping(ctx context.Context) (int, error) {
+	registry := c.Registry
+	if !strings.HasSuffix(registry, "/") {
+		registry += "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registry+"-/ping", nil)
+	if err != nil {
+		return 0, err
+	}
+	c.authorize(req, registry)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, networkError(ctx, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// fetch requests a packument with the given Accept header and returns it with
+// its ETag. When etag is set and still current, the packument is nil.
+func (c *RegistryClient) fetch(ctx context.Context, packageName, accept, etag string) (*Packument, string, error) {
+	registry := c.RegistryFor(packageName)
+	if !strings.HasSuffix(registry, "/") {
+		registry += "/"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, registry+url.PathEscape(packageName), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", accept)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	c.authorize(req, registry)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", networkError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, etag, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", withKind(fmt.Errorf("package %s not found in registry %s", packageName, registry), ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("registry returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			// The registry or a proxy in front of it is unavailable
+			err = withKind(err, ErrNetwork)
+		}
+		return nil, "", err
+	}
+
+	var packument Packument
+	if err := json.NewDecoder(resp.Body).Decode(&packument); err != nil {
+		return nil, "", fmt.Errorf("failed to parse packument of %s: %w", packageName, err)
+	}
+	return &packument, resp.Header.Get("ETag"), nil
+}
+
+// networkError marks a failed request as a network error, unless it failed
+// because ctx was canceled
+func networkError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return withKind(err, ErrNetwork)
+}
+
+// authorize adds the credentials configured for the registry, matching the
+// longest nerf-darted prefix of its URL
+func (c *RegistryClient) authorize(req *http.Request, registry string) {
+	nerfed := registry
+	if i := strings.Index(nerfed, "//"); i >= 0 {
+		nerfed = nerfed[i:]
+	}
+
+	var best string
+	for prefix := range c.Credentials {
+		if strings.HasPrefix(nerfed, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return
+	}
+
+	credential := c.Credentials[best]
+	switch {
+	case credential.Token != "":
+		req.Header.Set("Authorization", "Bearer "+credential.Token)
+	case credential.Auth != "":
+		req.Header.Set("Authorization", "Basic "+credential.Auth)
+	}
+}
@@ -0,0 +1,240 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestRegistry creates a client whose default registry is server
+func newTestRegistry(server *httptest.Server) *RegistryClient {
+	return &RegistryClient{
+		Registry:        server.URL + "/",
+		ScopeRegistries: make(map[string]string),
+		Credentials:     make(map[string]Credential),
+		HTTPClient:      server.Client(),
+	}
+}
+
+// nerfDart returns the .npmrc credential prefix of a server, e.g. //127.0.0.1:1234/
+func nerfDart(server *httptest.Server) string {
+	return strings.TrimPrefix(server.URL, "http:") + "/"
+}
+
+func TestPackumentAbbreviatedAndETag(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/fake-cli" {
+			t.Errorf("requested %s, want /fake-cli", r.URL.Path)
+		}
+		if accept := r.Header.Get("Accept"); !strings.HasPrefix(accept, "application/vnd.npm.install-v1+json") {
+			t.Errorf("Accept = %q, want the abbreviated packument format", accept)
+		}
+		if r.Header.Get("If-None-Match") == `"v2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte(`{"name":"fake-cli","dist-tags":{"latest":"1.2.0","next":"2.0.0-beta.1"},"versions":{"1.0.0":{},"1.2.0":{},"2.0.0-beta.1":{}}}`))
+	}))
+	defer server.Close()
+	client := newTestRegistry(server)
+	ctx := context.Background()
+
+	packument, err := client.Packument(ctx, "fake-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := packument.DistTags["latest"]; got != "1.2.0" {
+		t.Errorf("latest = %q, want 1.2.0", got)
+	}
+
+	result, err := client.LatestVersionIfChanged(ctx, "fake-cli", "^1.0.0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Version != "1.2.0" || result.ETag != `"v2"` || result.NotModified {
+		t.Errorf("first lookup = %+v, want version 1.2.0 with ETag \"v2\"", result)
+	}
+
+	result, err = client.LatestVersionIfChanged(ctx, "fake-cli", "^1.0.0", `"v2"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.NotModified || result.Version != "" || result.ETag != `"v2"` {
+		t.Errorf("conditional lookup = %+v, want NotModified with the same ETag", result)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+}
+
+func TestLoadNpmrcRegistries(t *testing.T) {
+	t.Setenv("TEST_NPM_TOKEN", "secret")
+	path := filepath.Join(t.TempDir(), ".npmrc")
+	npmrc := strings.Join([]string{
+		"# comment",
+		"registry=https://mirror.example.com/npm/",
+		`@acme:registry="https://npm.acme.dev/"`,
+		"//npm.acme.dev/:_authToken=${TEST_NPM_TOKEN}",
+		"//mirror.example.com/npm/:_auth=dXNlcjpwYXNz",
+		"https-proxy=http://proxy:8080",
+		"not a setting",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(npmrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &RegistryClient{Registry: DefaultRegistry, ScopeRegistries: map[string]string{}, Credentials: map[string]Credential{}}
+	if err := client.LoadNpmrc(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		packageName string
+		want        string
+	}{
+		{"fake-cli", "https://mirror.example.com/npm/"},
+		{"@acme/cli", "https://npm.acme.dev/"},
+		{"@other/cli", "https://mirror.example.com/npm/"},
+	}
+	for _, tt := range tests {
+		if got := client.RegistryFor(tt.packageName); got != tt.want {
+			t.Errorf("RegistryFor(%q) = %q, want %q", tt.packageName, got, tt.want)
+		}
+	}
+
+	if got := client.Credentials["//npm.acme.dev/"].Token; got != "secret" {
+		t.Errorf("token = %q, want the expanded ${TEST_NPM_TOKEN}", got)
+	}
+	if got := client.Credentials["//mirror.example.com/npm/"].Auth; got != "dXNlcjpwYXNz" {
+		t.Errorf("_auth = %q, want dXNlcjpwYXNz", got)
+	}
+	if client.HTTPSProxy != "http://proxy:8080" {
+		t.Errorf("https-proxy = %q, want http://proxy:8080", client.HTTPSProxy)
+	}
+}
+
+func TestCredentialsOnlySentToMatchingHost(t *testing.T) {
+	authorization := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+		w.Write([]byte(`{"name":"pkg","dist-tags":{"latest":"1.0.0"}}`))
+	})
+	private := httptest.NewServer(handler)
+	defer private.Close()
+	public := httptest.NewServer(handler)
+	defer public.Close()
+
+	tests := []struct {
+		name       string
+		credential Credential
+		registry   *httptest.Server
+		want       string
+	}{
+		{"token to its registry", Credential{Token: "t0ken"}, private, "Bearer t0ken"},
+		{"basic auth to its registry", Credential{Auth: "dXNlcjpwYXNz"}, private, "Basic dXNlcjpwYXNz"},
+		{"token withheld from another registry", Credential{Token: "t0ken"}, public, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestRegistry(tt.registry)
+			client.Credentials[nerfDart(private)] = tt.credential
+
+			if _, err := client.LatestVersion(context.Background(), "pkg"); err != nil {
+				t.Fatal(err)
+			}
+			if got := <-authorization; got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopedRegistryCredentials(t *testing.T) {
+	var defaultAuth atomic.Value
+	defaultAuth.Store("")
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultAuth.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{"name":"pkg","dist-tags":{"latest":"1.0.0"}}`))
+	}))
+	defer public.Close()
+	scoped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer scoped" {
+			t.Errorf("scoped registry got Authorization %q, want Bearer scoped", got)
+		}
+		w.Write([]byte(`{"name":"@acme/cli","dist-tags":{"latest":"3.0.0"}}`))
+	}))
+	defer scoped.Close()
+
+	client := newTestRegistry(public)
+	client.ScopeRegistries["@acme"] = scoped.URL + "/"
+	client.Credentials[nerfDart(scoped)] = Credential{Token: "scoped"}
+	ctx := context.Background()
+
+	if latest, err := client.LatestVersion(ctx, "@acme/cli"); err != nil || latest != "3.0.0" {
+		t.Errorf("LatestVersion(@acme/cli) = %q, %v; want 3.0.0 from the scoped registry", latest, err)
+	}
+	if _, err := client.LatestVersion(ctx, "pkg"); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultAuth.Load(); got != "" {
+		t.Errorf("default registry got Authorization %q, want none", got)
+	}
+}
+
+func TestRegistryErrorKinds(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+	}{
+		{"missing package", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, ErrNotFound},
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, ErrNetwork},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}, ErrNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			client := newTestRegistry(server)
+			client.HTTPClient.Timeout = 100 * time.Millisecond
+
+			_, err := client.Packument(context.Background(), "pkg")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanceledRequestIsNotANetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := newTestRegistry(server).Packument(ctx, "pkg")
+	if err == nil || errors.Is(err, ErrNetwork) {
+		t.Errorf("error = %v, want a cancellation that is not ErrNetwork", err)
+	}
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// userAgent identifies atm to package indexes, some of which (crates.io,
+// the GitHub API) reject requests without one
+const userAgent = "atm (https://github.com/xiaoxu123195/atm)"
+
+// Installer installs tools from a package source other than the npm
+// registry, such as PyPI or crates.io. Commands started by its methods are
+// stopped when ctx is done.
+type Installer interface {
+	// Name returns the source name used in the catalog
+	Name() string
+	// Command returns the executable the installer runs, or "" if it needs none
+	Command() string
+	// Install installs a package at an exact version, or the latest if version is empty
+	Install(ctx context.Context, packageName, version string) error
+	// Update updates an installed package to its latest version
+	Update(ctx context.Context, packageName string) error
+	// Uninstall removes an installed package
+	Uninstall(ctx context.Context, packageName string) error
+	// InstalledVersions returns the version of each of the packages that is
+	// installed, listing the installed packages only once
+	InstalledVersions(ctx context.Context, packageNames []string) (map[string]string, error)
+	// LatestVersion returns the latest release of a package in its index
+	LatestVersion(ctx context.Context, packageName string) (string, error)
+}
+
+// RepositoryFinder is implemented by installers that can find the source
+// repository of a package, where its release notes are published
+type RepositoryFinder interface {
+	Repository(ctx context.Context, packageName string) (Repository, error)
+}
+
+// InstallerOptions configures the installers created by NewInstaller
+type InstallerOptions struct {
+	// HTTPClient makes the requests to package indexes
+	HTTPClient *http.Client
+	// BinDir is where binaries downloaded from GitHub releases are installed
+	BinDir string
+	// StateDir is where the versions of downloaded binaries are recorded
+	StateDir string
+	// ReleaseBins names the executables installed from the releases of
+	// some repositories, by "owner/repo"
+	ReleaseBins map[string][]string
+	// GitHub reads GitHub releases; nil means the public API with HTTPClient
+	GitHub *github.Client
+}
+
+// NewInstaller returns the installer for a package source
+func NewInstaller(source string, options InstallerOptions) (Installer, error) {
+	switch source {
+	case "pipx":
+		return &PipxInstaller{client: options.HTTPClient}, nil
+	case "cargo":
+		return &CargoInstaller{client: options.HTTPClient}, nil
+	case "go":
+		return &GoInstaller{}, nil
+	case "github-release":
+		installer := NewGitHubInstaller(options.HTTPClient, options.BinDir, options.StateDir)
+		installer.Bins = options.ReleaseBins
+		if options.GitHub != nil {
+			installer.Client = options.GitHub
+		}
+		return installer, nil
+	default:
+		return nil, fmt.Errorf("unknown package source %q", source)
+	}
+}
+
+// getJSON requests url and decodes its JSON response into v. A 404 response
+// is reported as ErrNotFound and a server error as ErrNetwork.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return networkError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, url); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return nil
+}
+
+// checkStatus turns an unsuccessful HTTP response into an error
+func checkStatus(resp *http.Response, url string) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return withKind(fmt.Errorf("%s was not found", url), ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			err = withKind(err, ErrNetwork)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"context"
+	"path/filepath"
+)
+
+// YarnBackend manages global packages with Yarn classic (v1)
+type YarnBackend struct {
+	store globalStore
+}
+
+// Name returns the package manager executable name
+func (b *YarnBackend) Name() string {
+	return "yarn"
+}
+
+// Install installs a package globally
+func (b *YarnBackend) Install(ctx context.Context, packageSpec string) error {
+	_, err := run(ctx, "yarn", "global", "add", packageSpec)
+	return err
+}
+
+// Update updates a package to the latest version
+func (b *YarnBackend) Update(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "yarn", "global", "upgrade", packageName, "--latest")
+	return err
+}
+
+// Uninstall removes a global package
+func (b *YarnBackend) Uninstall(ctx context.Context, packageName string) error {
+	_, err := run(ctx, "yarn", "global", "remove", packageName)
+	return err
+}
+
+// InstalledVersion returns the version installed in the global store
+func (b *YarnBackend) InstalledVersion(ctx context.Context, packageName string) (string, error) {
+	return b.store.installedVersion(ctx, b.globalRoot, packageName)
+}
+
+// InstalledPackages lists the global store
+func (b *YarnBackend) InstalledPackages(ctx context.Context) (map[string]string, error) {
+	return b.store.installedPackages(ctx, b.globalRoot)
+}
+
+// GlobalRoot returns the global node_modules directory
+func (b *YarnBackend) GlobalRoot(ctx context.Context) (string, error) {
+	return b.store.resolve(ctx, b.globalRoot)
+}
+
+// GlobalBin returns the directory global executables are linked into (`yarn global bin`)
+func (b *YarnBackend) GlobalBin(ctx context.Context) (string, error) {
+	return run(ctx, "yarn", "global", "bin")
+}
+
+// globalRoot returns the node_modules directory under `yarn global dir`
+func (b *YarnBackend) globalRoot(ctx context.Context) (string, error) {
+	dir, err := run(ctx, "yarn", "global", "dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "node_modules"), nil
+}
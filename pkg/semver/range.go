@@ -0,0 +1,332 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// operatorSpace matches whitespace between an operator and its version, as in ">= 1.2.3"
+var operatorSpace = regexp.MustCompile(`([<>=~^]+)\s+`)
+
+// comparator is a single bound such as ">=1.2.3"
+type comparator struct {
+	op      string
+	version Version
+}
+
+// matches reports whether v satisfies the bound
+func (c comparator) matches(v Version) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Constraint is an npm-style version range such as "^1.2", "~1.2.3",
+// ">=1.0.0 <2.0.0", "1.2.x" or "1.2.3 - 2.0.0 || ^3"
+type Constraint struct {
+	raw string
+	// sets are alternatives separated by "||"; every comparator in a set must match
+	sets [][]comparator
+}
+
+// ParseConstraint parses an npm-style version range
+func ParseConstraint(s string) (*Constraint, error) {
+	constraint := &Constraint{raw: s}
+
+	for _, part := range strings.Split(s, "||") {
+		part = strings.TrimSpace(part)
+		var set []comparator
+		var err error
+		if lower, upper, found := strings.Cut(part, " - "); found {
+			set, err = parseHyphen(strings.TrimSpace(lower), strings.TrimSpace(upper))
+		} else {
+			set, err = parseComparators(part)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+		}
+		constraint.sets = append(constraint.sets, set)
+	}
+
+	return constraint, nil
+}
+
+// String returns the range as it was written
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the range. Following npm, a prerelease
+// only satisfies a set that names a prerelease of the same major.minor.patch,
+// so "^1.2.0" never selects "1.3.0-beta".
+func (c *Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		if setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// setMatches reports whether v satisfies every comparator of a set
+func setMatches(set []comparator, v Version) bool {
+	for _, comp := range set {
+		if !comp.matches(v) {
+			return false
+		}
+	}
+
+	if v.Prerelease == "" {
+		return true
+	}
+	for _, comp := range set {
+		if comp.version.Prerelease != "" && compareCore(comp.version, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxSatisfying returns the highest of versions that satisfies the range, or
+// "" if none does. Strings that are not valid versions are ignored.
+func (c *Constraint) MaxSatisfying(versions []string) string {
+	var best string
+	var bestVersion Version
+	for _, s := range versions {
+		v, err := Parse(s)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		if best == "" || Compare(v, bestVersion) > 0 {
+			best, bestVersion = s, v
+		}
+	}
+	return best
+}
+
+// partial is a possibly incomplete version such as "1", "1.2" or "1.x".
+// Missing and wildcard components are -1.
+type partial struct {
+	major, minor, patch int64
+	prerelease          string
+}
+
+// parsePartial parses a partial version
+func parsePartial(s string) (partial, error) {
+	p := partial{major: -1, minor: -1, patch: -1}
+	s = strings.TrimPrefix(s, "v")
+	if s == "" {
+		return p, nil
+	}
+
+	if core, _, found := strings.Cut(s, "+"); found {
+		s = core
+	}
+	if core, prerelease, found := strings.Cut(s, "-"); found {
+		s, p.prerelease = core, prerelease
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return p, fmt.Errorf("invalid version %q", s)
+	}
+	fields := []*int64{&p.major, &p.minor, &p.patch}
+	wildcard := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wildcard = true
+			continue
+		}
+		if wildcard {
+			return p, fmt.Errorf("invalid version %q", s)
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("invalid version %q", s)
+		}
+		*fields[i] = n
+	}
+
+	if p.prerelease != "" && p.patch < 0 {
+		return p, fmt.Errorf("invalid version %q", s)
+	}
+	return p, nil
+}
+
+// lowest returns the lowest version the partial can stand for
+func (p partial) lowest() Version {
+	return Version{Major: clamp(p.major), Minor: clamp(p.minor), Patch: clamp(p.patch), Prerelease: p.prerelease}
+}
+
+// full reports whether every component is present
+func (p partial) full() bool {
+	return p.patch >= 0
+}
+
+// nextMajor, nextMinor and nextPatch return the exclusive upper bound "-0"
+// version used by caret, tilde and x-ranges
+func nextMajor(p partial) Version {
+	return Version{Major: uint64(p.major) + 1, Prerelease: "0"}
+}
+
+func nextMinor(p partial) Version {
+	return Version{Major: uint64(p.major), Minor: uint64(p.minor) + 1, Prerelease: "0"}
+}
+
+func nextPatch(p partial) Version {
+	return Version{Major: uint64(p.major), Minor: uint64(p.minor), Patch: uint64(p.patch) + 1, Prerelease: "0"}
+}
+
+// clamp maps a missing component to zero
+func clamp(n int64) uint64 {
+	if n < 0 {
+		return 0
+	}
+	return uint64(n)
+}
+
+// parseComparators parses a whitespace-separated list of comparators
+func parseComparators(s string) ([]comparator, error) {
+	s = operatorSpace.ReplaceAllString(s, "$1")
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+
+	var set []comparator
+	for _, field := range fields {
+		comps, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comps...)
+	}
+	return set, nil
+}
+
+// parseComparator expands one comparator, which may be a caret, tilde or
+// x-range, into primitive bounds
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, candidate) {
+			op, s = candidate, s[len(candidate):]
+			break
+		}
+	}
+
+	p, err := parsePartial(s)
+	if err != nil {
+		return nil, err
+	}
+	anyVersion := []comparator{{op: ">=", version: Version{}}}
+
+	switch op {
+	case "^":
+		if p.major < 0 {
+			return anyVersion, nil
+		}
+		lower := comparator{op: ">=", version: p.lowest()}
+		switch {
+		case p.major > 0 || p.minor < 0:
+			return []comparator{lower, {op: "<", version: nextMajor(p)}}, nil
+		case p.minor > 0 || p.patch < 0:
+			return []comparator{lower, {op: "<", version: nextMinor(p)}}, nil
+		default:
+			return []comparator{lower, {op: "<", version: nextPatch(p)}}, nil
+		}
+
+	case "~":
+		if p.major < 0 {
+			return anyVersion, nil
+		}
+		lower := comparator{op: ">=", version: p.lowest()}
+		if p.minor < 0 {
+			return []comparator{lower, {op: "<", version: nextMajor(p)}}, nil
+		}
+		return []comparator{lower, {op: "<", version: nextMinor(p)}}, nil
+
+	case ">":
+		switch {
+		case p.major < 0:
+			// Nothing is greater than every version
+			return []comparator{{op: "<", version: Version{Prerelease: "0"}}}, nil
+		case p.minor < 0:
+			return []comparator{{op: ">=", version: Version{Major: uint64(p.major) + 1}}}, nil
+		case p.patch < 0:
+			return []comparator{{op: ">=", version: Version{Major: uint64(p.major), Minor: uint64(p.minor) + 1}}}, nil
+		}
+		return []comparator{{op: ">", version: p.lowest()}}, nil
+
+	case ">=":
+		return []comparator{{op: ">=", version: p.lowest()}}, nil
+
+	case "<":
+		if p.major < 0 {
+			return []comparator{{op: "<", version: Version{Prerelease: "0"}}}, nil
+		}
+		if p.full() {
+			return []comparator{{op: "<", version: p.lowest()}}, nil
+		}
+		return []comparator{{op: "<", version: Version{Major: clamp(p.major), Minor: clamp(p.minor), Prerelease: "0"}}}, nil
+
+	case "<=":
+		switch {
+		case p.major < 0:
+			return anyVersion, nil
+		case p.minor < 0:
+			return []comparator{{op: "<", version: nextMajor(p)}}, nil
+		case p.patch < 0:
+			return []comparator{{op: "<", version: nextMinor(p)}}, nil
+		}
+		return []comparator{{op: "<=", version: p.lowest()}}, nil
+
+	default:
+		// "=" or a bare version, which may be an x-range
+		switch {
+		case p.major < 0:
+			return anyVersion, nil
+		case p.minor < 0:
+			return []comparator{{op: ">=", version: p.lowest()}, {op: "<", version: nextMajor(p)}}, nil
+		case p.patch < 0:
+			return []comparator{{op: ">=", version: p.lowest()}, {op: "<", version: nextMinor(p)}}, nil
+		}
+		return []comparator{{op: "=", version: p.lowest()}}, nil
+	}
+}
+
+// parseHyphen expands an inclusive range such as "1.2 - 2.3.4"
+func parseHyphen(lower, upper string) ([]comparator, error) {
+	from, err := parsePartial(lower)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parsePartial(upper)
+	if err != nil {
+		return nil, err
+	}
+
+	set := []comparator{{op: ">=", version: from.lowest()}}
+	switch {
+	case to.major < 0:
+	case to.minor < 0:
+		set = append(set, comparator{op: "<", version: nextMajor(to)})
+	case to.patch < 0:
+		set = append(set, comparator{op: "<", version: nextMinor(to)})
+	default:
+		set = append(set, comparator{op: "<=", version: to.lowest()})
+	}
+	return set, nil
+}
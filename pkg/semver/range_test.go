@@ -0,0 +1,82 @@
+package semver
+
+import "testing"
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0", "2.0.0-0", "1.3.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"2.0.0", "1.1.9"}},
+		{"^0.x", []string{"0.0.1", "0.9.0"}, []string{"1.0.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=1.0.0 <2.0.0", []string{"1.0.0", "1.99.99"}, []string{"0.9.9", "2.0.0"}},
+		{">= 1.0.0", []string{"1.0.0", "3.0.0"}, []string{"0.1.0"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0"}},
+		{"1.2.x", []string{"1.2.0", "1.2.99"}, []string{"1.3.0"}},
+		{"1.x", []string{"1.0.0", "1.99.0"}, []string{"2.0.0"}},
+		{"*", []string{"0.0.0", "99.0.0"}, []string{"1.0.0-beta"}},
+		{"", []string{"1.0.0"}, nil},
+		{"1.2.3", []string{"1.2.3", "v1.2.3", "1.2.3+build"}, []string{"1.2.4"}},
+		{"1.2.3 - 2.3.4", []string{"1.2.3", "2.3.4"}, []string{"1.2.2", "2.3.5"}},
+		{"1.2 - 2.3", []string{"1.2.0", "2.3.9"}, []string{"2.4.0"}},
+		{"^1.0.0 || ^3.0.0", []string{"1.5.0", "3.1.0"}, []string{"2.0.0"}},
+		{">=1.2.3-beta.2 <1.3.0", []string{"1.2.3-beta.2", "1.2.3-beta.10", "1.2.3"}, []string{"1.2.3-beta.1", "1.2.4-beta.1"}},
+		{"^2.0.0-rc.1", []string{"2.0.0-rc.1", "2.0.0-rc.2", "2.1.0"}, []string{"2.0.0-beta", "2.1.0-rc.1"}},
+	}
+	for _, tt := range tests {
+		constraint, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) failed: %v", tt.constraint, err)
+			continue
+		}
+		for _, version := range tt.matches {
+			if !constraint.Check(MustParse(version)) {
+				t.Errorf("%q does not match %s, want a match", tt.constraint, version)
+			}
+		}
+		for _, version := range tt.rejects {
+			if constraint.Check(MustParse(version)) {
+				t.Errorf("%q matches %s, want no match", tt.constraint, version)
+			}
+		}
+	}
+}
+
+func TestParseConstraintErrors(t *testing.T) {
+	for _, input := range []string{"latest", "next", "^1.2.3.4", "1.x.2", ">=a.b.c", "~1.2-beta"} {
+		if _, err := ParseConstraint(input); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"1.0.0", "1.2.0", "1.10.0", "2.0.0-beta.1", "2.0.0", "2.1.0-rc.1", "garbage"}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.0.0", "1.10.0"},
+		{"~1.2.0", "1.2.0"},
+		{"*", "2.0.0"},
+		{">=2.0.0-beta.1 <2.0.0", "2.0.0-beta.1"},
+		{"^3.0.0", ""},
+	}
+	for _, tt := range tests {
+		constraint, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := constraint.MaxSatisfying(versions); got != tt.want {
+			t.Errorf("MaxSatisfying(%q) = %q, want %q", tt.constraint, got, tt.want)
+		}
+	}
+}
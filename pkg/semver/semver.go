@@ -0,0 +1,203 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string
+	Build      string
+}
+
+// Parse parses a SemVer 2.0 version such as "1.2.3", "v1.2.3" or
+// "1.2.3-beta.1+build.5". A leading "v" or "=" is accepted.
+func Parse(s string) (Version, error) {
+	var v Version
+	text := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "="), "v")
+
+	if core, build, found := strings.Cut(text, "+"); found {
+		if !validIdentifiers(build, false) {
+			return Version{}, fmt.Errorf("invalid version %q: bad build metadata", s)
+		}
+		text, v.Build = core, build
+	}
+	if core, prerelease, found := strings.Cut(text, "-"); found {
+		if !validIdentifiers(prerelease, true) {
+			return Version{}, fmt.Errorf("invalid version %q: bad prerelease", s)
+		}
+		text, v.Prerelease = core, prerelease
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		if !numeric(part) || (len(part) > 1 && part[0] == '0') {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+
+	return v, nil
+}
+
+// validIdentifiers checks a dot-separated list of prerelease or build
+// identifiers. Numeric prerelease identifiers must not have leading zeros.
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+		if prerelease && numeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// numeric reports whether s is a non-empty string of digits
+func numeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MustParse is like Parse but panics on invalid input. It is intended for constants.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String formats the version without a "v" prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 depending on whether a has lower, equal or
+// higher precedence than b. Prerelease identifiers are compared one by one:
+// numeric identifiers numerically, others in ASCII order, and numeric ones
+// before alphanumeric ones. A prerelease precedes the release and build
+// metadata is ignored.
+func Compare(a, b Version) int {
+	if c := compareCore(a, b); c != 0 {
+		return c
+	}
+
+	switch {
+	case a.Prerelease == b.Prerelease:
+		return 0
+	case a.Prerelease == "":
+		return 1
+	case b.Prerelease == "":
+		return -1
+	}
+
+	ids1 := strings.Split(a.Prerelease, ".")
+	ids2 := strings.Split(b.Prerelease, ".")
+	for i := 0; i < len(ids1) && i < len(ids2); i++ {
+		if c := compareIdentifier(ids1[i], ids2[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(ids1), len(ids2))
+}
+
+// compareIdentifier compares two prerelease identifiers
+func compareIdentifier(a, b string) int {
+	aNumeric, bNumeric := numeric(a), numeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		// No leading zeros, so the longer number is the larger one
+		if c := compareInt(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInt compares two ints
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Sort sorts versions in ascending order of precedence. Versions that differ
+// only in build metadata keep their relative order.
+func Sort(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
+
+// Newer reports whether candidate has higher precedence than current. It
+// returns false if either string is not a valid version.
+func Newer(candidate, current string) bool {
+	c, err := Parse(candidate)
+	if err != nil {
+		return false
+	}
+	v, err := Parse(current)
+	if err != nil {
+		return false
+	}
+	return Compare(c, v) > 0
+}
+
+// compareCore compares the major, minor and patch numbers
+func compareCore(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+	return 0
+}
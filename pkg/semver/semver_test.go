@@ -0,0 +1,148 @@
+package semver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Version
+	}{
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"=1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{" 0.0.0 ", Version{}},
+		{"1.2.3-beta.1", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta.1"}},
+		{"1.2.3+build.5", Version{Major: 1, Minor: 2, Patch: 3, Build: "build.5"}},
+		{"1.2.3-rc-1.0+exp.sha.5114f85", Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc-1.0", Build: "exp.sha.5114f85"}},
+		{"1.0.0+001", Version{Major: 1, Build: "001"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"1",
+		"1.2",
+		"1.2.3.4",
+		"01.2.3",
+		"1.02.3",
+		"1.2.x",
+		"a.b.c",
+		"-1.2.3",
+		"1.2.3-",
+		"1.2.3-beta..1",
+		"1.2.3-01",
+		"1.2.3-beta_1",
+		"1.2.3+",
+		"1.2.3+build!",
+		"99999999999999999999.0.0",
+	} {
+		if v, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", input, v)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, input := range []string{"1.2.3", "0.0.1-alpha.1", "1.0.0+build", "2.0.0-rc.1+sha.abc"} {
+		if got := MustParse(input).String(); got != input {
+			t.Errorf("MustParse(%q).String() = %q", input, got)
+		}
+	}
+}
+
+// TestCompareOrder checks the precedence example of the SemVer specification
+// and a few more, each version lower than the next
+func TestCompareOrder(t *testing.T) {
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-0",
+		"1.0.0-1",
+		"1.0.0-2",
+		"1.0.0-10",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := Compare(MustParse(ordered[i]), MustParse(ordered[j])); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCompareIgnoresBuildMetadata(t *testing.T) {
+	tests := [][2]string{
+		{"1.0.0+build.1", "1.0.0+build.2"},
+		{"1.0.0", "1.0.0+20240101"},
+		{"1.0.0-beta+a", "1.0.0-beta+b"},
+	}
+	for _, tt := range tests {
+		if got := Compare(MustParse(tt[0]), MustParse(tt[1])); got != 0 {
+			t.Errorf("Compare(%s, %s) = %d, want 0", tt[0], tt[1], got)
+		}
+	}
+}
+
+func TestSortIsStable(t *testing.T) {
+	versions := []Version{MustParse("2.0.0"), MustParse("1.0.0+b"), MustParse("1.0.0-rc.1"), MustParse("1.0.0+a")}
+	Sort(versions)
+
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.String())
+	}
+	if want := "1.0.0-rc.1 1.0.0+b 1.0.0+a 2.0.0"; strings.Join(got, " ") != want {
+		t.Errorf("Sort = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		candidate, current string
+		want               bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.2.0", "1.2.0", false},
+		{"1.2.0", "1.2.0-rc.1", true},
+		{"1.2.0-rc.1", "1.2.0", false},
+		{"1.2.0+build", "1.2.0", false},
+		{"not-a-version", "1.0.0", false},
+		{"1.0.0", "dev", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.candidate, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.candidate, tt.current, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"bufio"
+)
+
+// KeyCode identifies a special key; printable keys are reported by their rune
+type KeyCode int
+
+// Special keys
+const (
+	KeyNone KeyCode = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyCtrlC
+)
+
+// Key is a key press read from a terminal in raw mode
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+// ReadKey reads the next key press. Escape sequences for the arrow and
+// paging keys are decoded; other sequences are read and ignored.
+func ReadKey(reader *bufio.Reader) (Key, error) {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch r {
+	case 3:
+		return Key{Code: KeyCtrlC}, nil
+	case '\r', '\n':
+		return Key{Code: KeyEnter}, nil
+	case 8, 127:
+		return Key{Code: KeyBackspace}, nil
+	case 27:
+		// A lone Esc arrives without the rest of a sequence
+		if reader.Buffered() == 0 {
+			return Key{Code: KeyEsc}, nil
+		}
+		return readEscape(reader)
+	}
+	return Key{Rune: r}, nil
+}
+
+// readEscape reads the rest of an escape sequence such as ESC [ A or ESC [ 5 ~
+func readEscape(reader *bufio.Reader) (Key, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+	if b != '[' && b != 'O' {
+		return Key{}, nil
+	}
+
+	// Read parameters such as the 1;5 in ESC [ 1 ; 5 A up to the final byte
+	var params []byte
+	for {
+		b, err = reader.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+		if b < '0' || b > '?' {
+			break
+		}
+		params = append(params, b)
+	}
+
+	switch b {
+	case 'A':
+		return Key{Code: KeyUp}, nil
+	case 'B':
+		return Key{Code: KeyDown}, nil
+	case 'H':
+		return Key{Code: KeyHome}, nil
+	case 'F':
+		return Key{Code: KeyEnd}, nil
+	case '~':
+		switch string(params) {
+		case "5":
+			return Key{Code: KeyPageUp}, nil
+		case "6":
+			return Key{Code: KeyPageDown}, nil
+		case "1", "7":
+			return Key{Code: KeyHome}, nil
+		case "4", "8":
+			return Key{Code: KeyEnd}, nil
+		}
+	}
+	return Key{}, nil
+}
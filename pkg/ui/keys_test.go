@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Key
+	}{
+		{"up", "\x1b[A", []Key{{Code: KeyUp}}},
+		{"down in application mode", "\x1bOB", []Key{{Code: KeyDown}}},
+		{"page up", "\x1b[5~", []Key{{Code: KeyPageUp}}},
+		{"page down", "\x1b[6~", []Key{{Code: KeyPageDown}}},
+		{"home and end", "\x1b[H\x1b[4~", []Key{{Code: KeyHome}, {Code: KeyEnd}}},
+		{"up with modifiers", "\x1b[1;5A", []Key{{Code: KeyUp}}},
+		{"lone escape", "\x1b", []Key{{Code: KeyEsc}}},
+		{"unknown sequence is ignored", "\x1b[15~x", []Key{{}, {Rune: 'x'}}},
+		{"alt key is ignored", "\x1bx", []Key{{}}},
+		{"control keys", "\x03\r\n\x7f\x08", []Key{{Code: KeyCtrlC}, {Code: KeyEnter}, {Code: KeyEnter}, {Code: KeyBackspace}, {Code: KeyBackspace}}},
+		{"runes", "a 界", []Key{{Rune: 'a'}, {Rune: ' '}, {Rune: '界'}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			var keys []Key
+			for {
+				k, err := ReadKey(reader)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				keys = append(keys, k)
+			}
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("keys = %+v, want %+v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadKeyTruncatedSequence(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("\x1b[5"))
+	if _, err := ReadKey(reader); err != io.EOF {
+		t.Errorf("error = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,291 @@
+// Package ui provides terminal prompts that promptui does not offer
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+var (
+	// ErrInterrupt is returned when the user presses Ctrl+C
+	ErrInterrupt = errors.New("^C")
+	// ErrAbort is returned when the user leaves the prompt with Esc or q
+	ErrAbort = errors.New("aborted")
+	// ErrNotTerminal is returned when stdin or stdout is not a terminal
+	ErrNotTerminal = errors.New("not a terminal")
+)
+
+// defaultSize is the number of items shown at once unless Size is set
+const defaultSize = 10
+
+// ANSI escape sequences used to redraw the prompt
+const (
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+	clearDown  = "\x1b[J"
+)
+
+// MultiSelect is a checkbox list. Space toggles the current item, a toggles
+// all shown items, / filters the list and enter confirms the selection.
+type MultiSelect struct {
+	// Label is shown above the list
+	Label string
+	// Items are the choices, each shown on one line
+	Items []string
+	// Help explains the keys under the label
+	Help string
+	// FilterHelp explains the keys while typing a filter
+	FilterHelp string
+	// NoMatches is shown when the filter matches no item
+	NoMatches string
+	// Size is the number of items shown at once
+	Size int
+}
+
+// Run shows the list and returns the indexes of the selected items in the
+// order of Items. Each item can be selected only once.
+func (m *MultiSelect) Run() ([]int, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return nil, ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(in, state)
+
+	fmt.Print(hideCursor)
+	defer fmt.Print(showCursor)
+
+	s := &selection{items: m.Items, selected: make([]bool, len(m.Items))}
+	s.applyFilter()
+
+	size := m.Size
+	if size <= 0 {
+		size = defaultSize
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	lines := 0
+	for {
+		lines = m.render(s, size, lines)
+
+		k, err := ReadKey(reader)
+		if err != nil {
+			m.clear(lines)
+			return nil, err
+		}
+
+		if k.Code == KeyCtrlC {
+			m.clear(lines)
+			return nil, ErrInterrupt
+		}
+
+		if s.filtering {
+			s.filterKey(k)
+			continue
+		}
+
+		switch {
+		case k.Code == KeyUp || k.Rune == 'k':
+			s.move(-1)
+		case k.Code == KeyDown || k.Rune == 'j':
+			s.move(1)
+		case k.Rune == ' ':
+			s.toggle()
+		case k.Rune == 'a':
+			s.toggleAll()
+		case k.Rune == '/':
+			s.filtering = true
+		case k.Code == KeyEnter:
+			m.clear(lines)
+			chosen := s.chosen()
+			m.printResult(chosen)
+			return chosen, nil
+		case k.Code == KeyEsc || k.Rune == 'q':
+			m.clear(lines)
+			return nil, ErrAbort
+		}
+	}
+}
+
+// selection is the state of a MultiSelect while it runs
+type selection struct {
+	items    []string
+	selected []bool
+
+	filter    string
+	filtering bool
+	// shown holds the indexes of the items matching the filter
+	shown []int
+
+	cursor int // position in shown
+	top    int // first position of shown on screen
+}
+
+// applyFilter updates the shown items after the filter changed
+func (s *selection) applyFilter() {
+	query := strings.ToLower(s.filter)
+	s.shown = s.shown[:0]
+	for i, item := range s.items {
+		if strings.Contains(strings.ToLower(item), query) {
+			s.shown = append(s.shown, i)
+		}
+	}
+	s.cursor, s.top = 0, 0
+}
+
+// filterKey handles a key pressed while typing a filter
+func (s *selection) filterKey(k Key) {
+	switch {
+	case k.Code == KeyUp:
+		s.move(-1)
+	case k.Code == KeyDown:
+		s.move(1)
+	case k.Code == KeyEnter:
+		s.filtering = false
+	case k.Code == KeyEsc:
+		s.filter, s.filtering = "", false
+		s.applyFilter()
+	case k.Code == KeyBackspace:
+		if s.filter == "" {
+			s.filtering = false
+			return
+		}
+		runes := []rune(s.filter)
+		s.filter = string(runes[:len(runes)-1])
+		s.applyFilter()
+	case k.Rune != 0 && unicode.IsPrint(k.Rune):
+		s.filter += string(k.Rune)
+		s.applyFilter()
+	}
+}
+
+// move moves the cursor by delta, wrapping around the shown items
+func (s *selection) move(delta int) {
+	if len(s.shown) == 0 {
+		return
+	}
+	s.cursor = (s.cursor + delta + len(s.shown)) % len(s.shown)
+}
+
+// toggle selects or deselects the item under the cursor
+func (s *selection) toggle() {
+	if len(s.shown) == 0 {
+		return
+	}
+	i := s.shown[s.cursor]
+	s.selected[i] = !s.selected[i]
+}
+
+// toggleAll selects every shown item, or deselects them if all are selected
+func (s *selection) toggleAll() {
+	all := true
+	for _, i := range s.shown {
+		all = all && s.selected[i]
+	}
+	for _, i := range s.shown {
+		s.selected[i] = !all
+	}
+}
+
+// chosen returns the indexes of the selected items
+func (s *selection) chosen() []int {
+	var chosen []int
+	for i, selected := range s.selected {
+		if selected {
+			chosen = append(chosen, i)
+		}
+	}
+	return chosen
+}
+
+// render draws the prompt over the previous drawing of the given number of
+// lines and returns the number of lines drawn
+func (m *MultiSelect) render(s *selection, size, previous int) int {
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	}
+	// Keep the last column free so that lines never wrap
+	fit := func(text string, indent int) string { return Truncate(text, width-1-indent) }
+
+	gray := color.New(color.FgHiBlack)
+	var b strings.Builder
+	line := func(text string) {
+		b.WriteString(text)
+		b.WriteString("\r\n")
+	}
+
+	count := ""
+	if n := len(s.chosen()); n > 0 {
+		count = fmt.Sprintf(" (%d/%d)", n, len(s.items))
+	}
+	line(color.CyanString("?") + " " + color.New(color.Bold).Sprint(fit(m.Label+count, 2)))
+
+	switch {
+	case s.filtering:
+		filter := fit("/"+s.filter+"█", 0)
+		line(color.CyanString(filter) + " " + gray.Sprint(fit(m.FilterHelp, Width(filter)+1)))
+	case s.filter != "":
+		filter := fit("/"+s.filter, 0)
+		line(color.CyanString(filter) + " " + gray.Sprint(fit(m.Help, Width(filter)+1)))
+	default:
+		line(gray.Sprint(fit(m.Help, 0)))
+	}
+
+	if len(s.shown) == 0 {
+		line(gray.Sprint("  " + fit(m.NoMatches, 2)))
+	}
+
+	// Scroll so that the cursor stays on screen
+	if s.cursor < s.top {
+		s.top = s.cursor
+	} else if s.cursor >= s.top+size {
+		s.top = s.cursor - size + 1
+	}
+	end := min(s.top+size, len(s.shown))
+	for pos := s.top; pos < end; pos++ {
+		i := s.shown[pos]
+		box := "[ ]"
+		if s.selected[i] {
+			box = "[x]"
+		}
+		text := fit(box+" "+s.items[i], 2)
+		if pos == s.cursor {
+			line(color.CyanString("▸ " + text))
+		} else {
+			line("  " + text)
+		}
+	}
+
+	m.clear(previous)
+	fmt.Print(b.String())
+	return strings.Count(b.String(), "\n")
+}
+
+// clear erases the given number of lines above the cursor
+func (m *MultiSelect) clear(lines int) {
+	if lines > 0 {
+		fmt.Printf("\x1b[%dA\r", lines)
+	}
+	fmt.Print(clearDown)
+}
+
+// printResult leaves the label and the selected items on screen
+func (m *MultiSelect) printResult(chosen []int) {
+	names := make([]string, len(chosen))
+	for n, i := range chosen {
+		names[n] = m.Items[i]
+	}
+	fmt.Printf("%s %s %s\r\n", color.GreenString("✔"), m.Label, color.CyanString(strings.Join(names, ", ")))
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newSelection returns the state of a MultiSelect over items
+func newSelection(items ...string) *selection {
+	s := &selection{items: items, selected: make([]bool, len(items))}
+	s.applyFilter()
+	return s
+}
+
+// typeFilter opens the filter and types text into it
+func typeFilter(s *selection, text string) {
+	s.filtering = true
+	for _, r := range text {
+		s.filterKey(Key{Rune: r})
+	}
+}
+
+func TestSelectionFilter(t *testing.T) {
+	s := newSelection("Claude Code", "Codex", "Gemini CLI", "Aider")
+
+	typeFilter(s, "CO")
+	if want := []int{0, 1}; !reflect.DeepEqual(s.shown, want) {
+		t.Errorf("shown = %v, want %v", s.shown, want)
+	}
+
+	s.filterKey(Key{Code: KeyBackspace})
+	if s.filter != "C" || len(s.shown) != 3 {
+		t.Errorf("after backspace filter = %q, shown = %v", s.filter, s.shown)
+	}
+
+	typeFilter(s, "xyz")
+	s.move(1)
+	s.toggle()
+	if len(s.shown) != 0 || s.chosen() != nil {
+		t.Errorf("an empty filter result shows %v and chose %v", s.shown, s.chosen())
+	}
+
+	s.filterKey(Key{Code: KeyEsc})
+	if s.filtering || s.filter != "" || len(s.shown) != 4 {
+		t.Errorf("Esc left filter = %q, filtering = %v, shown = %v", s.filter, s.filtering, s.shown)
+	}
+
+	typeFilter(s, "a")
+	s.filterKey(Key{Code: KeyEnter})
+	if s.filtering || s.filter != "a" {
+		t.Errorf("Enter left filter = %q, filtering = %v; want the filter kept", s.filter, s.filtering)
+	}
+
+	s.filter = ""
+	s.applyFilter()
+	s.filtering = true
+	s.filterKey(Key{Code: KeyBackspace})
+	if s.filtering {
+		t.Error("backspace on an empty filter did not close it")
+	}
+}
+
+func TestSelectionToggleAll(t *testing.T) {
+	s := newSelection("Claude Code", "Codex", "Gemini CLI", "Aider")
+	s.selected[3] = true
+
+	typeFilter(s, "co")
+	s.toggleAll()
+	if want := []int{0, 1, 3}; !reflect.DeepEqual(s.chosen(), want) {
+		t.Errorf("chosen = %v, want the shown items added to %v", s.chosen(), want)
+	}
+
+	s.toggleAll()
+	if want := []int{3}; !reflect.DeepEqual(s.chosen(), want) {
+		t.Errorf("chosen = %v, want the shown items removed, leaving %v", s.chosen(), want)
+	}
+
+	s.filter = ""
+	s.applyFilter()
+	s.toggleAll()
+	if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(s.chosen(), want) {
+		t.Errorf("chosen = %v, want %v", s.chosen(), want)
+	}
+}
+
+func TestSelectionCursorWraps(t *testing.T) {
+	s := newSelection("a", "b", "c")
+	tests := []struct {
+		delta int
+		want  int
+	}{
+		{-1, 2},
+		{1, 0},
+		{1, 1},
+		{1, 2},
+		{1, 0},
+		{-1, 2},
+		{-1, 1},
+	}
+	for i, tt := range tests {
+		s.move(tt.delta)
+		if s.cursor != tt.want {
+			t.Fatalf("step %d: cursor = %d, want %d", i, s.cursor, tt.want)
+		}
+	}
+
+	s.toggle()
+	if want := []int{1}; !reflect.DeepEqual(s.chosen(), want) {
+		t.Errorf("chosen = %v, want %v", s.chosen(), want)
+	}
+
+	// The cursor moves over the filtered items and toggles the item under it
+	typeFilter(s, "c")
+	s.move(1)
+	s.toggle()
+	if want := []int{1, 2}; s.cursor != 0 || !reflect.DeepEqual(s.chosen(), want) {
+		t.Errorf("cursor = %d, chosen = %v; want 0 and %v", s.cursor, s.chosen(), want)
+	}
+}
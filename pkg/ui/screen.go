@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences for full-screen drawing
+const (
+	enterAltScreen = "\x1b[?1049h"
+	leaveAltScreen = "\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+)
+
+// Screen is a full-screen terminal UI on the alternate screen. It only uses
+// plain ANSI sequences, so it works in any terminal, including over SSH.
+type Screen struct {
+	in    int
+	out   int
+	state *term.State
+	keys  chan Key
+}
+
+// NewScreen switches the terminal to raw mode and the alternate screen and
+// starts reading key presses. Call Close to restore the terminal.
+func NewScreen() (*Screen, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return nil, ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	os.Stdout.WriteString(enterAltScreen + hideCursor)
+
+	s := &Screen{in: in, out: out, state: state, keys: make(chan Key)}
+	go s.readKeys()
+	return s, nil
+}
+
+// readKeys sends key presses to the Keys channel until stdin is closed
+func (s *Screen) readKeys() {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		k, err := ReadKey(reader)
+		if err != nil {
+			close(s.keys)
+			return
+		}
+		if k.Code != KeyNone || k.Rune != 0 {
+			s.keys <- k
+		}
+	}
+}
+
+// Keys returns the channel key presses are sent to. Reading continues after
+// Close, so a program should not read stdin itself once it used a Screen.
+func (s *Screen) Keys() <-chan Key {
+	return s.keys
+}
+
+// Size returns the width and height of the terminal
+func (s *Screen) Size() (int, int) {
+	width, height, err := term.GetSize(s.out)
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Draw replaces the screen contents with lines, which must fit the width
+// of the terminal. Lines beyond its height are not drawn.
+func (s *Screen) Draw(lines []string) {
+	_, height := s.Size()
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString(clearLine)
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString(clearDown)
+	os.Stdout.WriteString(b.String())
+}
+
+// Close leaves the alternate screen and restores the terminal
+func (s *Screen) Close() {
+	os.Stdout.WriteString(showCursor + leaveAltScreen)
+	term.Restore(s.in, s.state)
+}
@@ -0,0 +1,49 @@
+package ui
+
+import "strings"
+
+// Truncate shortens text to fit in the given number of terminal columns
+func Truncate(text string, columns int) string {
+	if Width(text) <= columns {
+		return text
+	}
+	if columns <= 0 {
+		return ""
+	}
+	used := 0
+	for i, r := range text {
+		// Leave a column for the ellipsis
+		if used+runeWidth(r) > columns-1 {
+			return text[:i] + "…"
+		}
+		used += runeWidth(r)
+	}
+	return text
+}
+
+// Width returns the number of terminal columns text takes up
+func Width(text string) int {
+	width := 0
+	for _, r := range text {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the number of terminal columns a rune takes up; East
+// Asian wide characters take two
+func runeWidth(r rune) int {
+	if r >= 0x1100 && (r <= 0x115f || (r >= 0x2e80 && r <= 0xa4cf) ||
+		(r >= 0xac00 && r <= 0xd7a3) || (r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) || (r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6)) {
+		return 2
+	}
+	return 1
+}
+
+// Pad truncates or pads text with spaces to exactly the given number of columns
+func Pad(text string, columns int) string {
+	text = Truncate(text, columns)
+	return text + strings.Repeat(" ", max(columns-Width(text), 0))
+}
@@ -1,19 +1,29 @@
 package version
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"strings"
 	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+	"github.com/xiaoxu123195/atm/pkg/semver"
 )
 
 // Checker handles version checking for ATM itself
 type Checker struct {
 	currentVersion string
 	repositoryURL  string
+	client         *github.Client
+
+	// cachePath is the file the result of the last check is kept in, and
+	// interval how long it is used before checking again
+	cachePath string
+	interval  time.Duration
 }
 
 // UpdateInfo contains information about available updates
@@ -22,13 +32,19 @@ type UpdateInfo struct {
 	CurrentVersion string
 	LatestVersion  string
 	RepositoryURL  string
-	Error          error
+	// Cached is set when the latest version was read from the cache instead of the API
+	Cached bool
+	Error  error
 }
 
-// GitHubRelease represents a GitHub release API response
-type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+// checkRecord is the cached result of the last successful check
+type checkRecord struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	LatestVersion string    `json:"latestVersion"`
+	// APIURL and Repository are where the release was read from; a record
+	// for another API or repository is not reused
+	APIURL     string `json:"apiURL"`
+	Repository string `json:"repository"`
 }
 
 // NewChecker creates a new version checker
@@ -36,60 +52,101 @@ func NewChecker(currentVersion, repositoryURL string) *Checker {
 	return &Checker{
 		currentVersion: currentVersion,
 		repositoryURL:  repositoryURL,
+		client:         github.NewClient(nil),
 	}
 }
 
-// CheckForUpdates checks if a newer version is available on GitHub
-func (c *Checker) CheckForUpdates() *UpdateInfo {
+// SetClient sets the client releases are read with, e.g. one for GitHub
+// Enterprise or with a token
+func (c *Checker) SetClient(client *github.Client) {
+	c.client = client
+}
+
+// SetCache keeps the result of a check in path and reuses it for interval.
+// A zero interval checks every time.
+func (c *Checker) SetCache(path string, interval time.Duration) {
+	c.cachePath, c.interval = path, interval
+}
+
+// CheckForUpdates checks if a newer version is available on GitHub, using
+// the result of a check within the interval instead if there is one. The
+// request is stopped when ctx is done.
+func (c *Checker) CheckForUpdates(ctx context.Context) *UpdateInfo {
 	info := &UpdateInfo{
 		CurrentVersion: c.currentVersion,
 		RepositoryURL:  c.repositoryURL,
 	}
 
-	// Extract owner and repo from URL
-	// Expected format: https://github.com/owner/repo
-	parts := strings.Split(strings.TrimPrefix(c.repositoryURL, "https://github.com/"), "/")
-	if len(parts) < 2 {
-		info.Error = fmt.Errorf("invalid repository URL")
-		return info
+	if record, ok := c.cachedCheck(); ok {
+		info.LatestVersion = record.LatestVersion
+		info.Cached = true
+	} else {
+		release, err := c.Release(ctx, "")
+		if err != nil {
+			info.Error = err
+			return info
+		}
+		info.LatestVersion = release.Version()
+		c.saveCheck(checkRecord{
+			CheckedAt:     time.Now(),
+			LatestVersion: info.LatestVersion,
+			APIURL:        c.client.APIURL,
+			Repository:    c.repositoryURL,
+		})
 	}
 
-	owner, repo := parts[0], parts[1]
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
+	// Compare versions by SemVer precedence, so a prerelease never replaces its release
+	info.HasUpdate = semver.Newer(info.LatestVersion, c.currentVersion)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	return info
+}
 
-	resp, err := client.Get(apiURL)
+// cachedCheck returns the result of the last check if it is within the
+// interval and was read from the same API and repository
+func (c *Checker) cachedCheck() (checkRecord, bool) {
+	if c.cachePath == "" || c.interval <= 0 {
+		return checkRecord{}, false
+	}
+	data, err := os.ReadFile(c.cachePath)
 	if err != nil {
-		info.Error = err
-		return info
+		return checkRecord{}, false
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		info.Error = fmt.Errorf("GitHub API returned status: %d", resp.StatusCode)
-		return info
+	var record checkRecord
+	if json.Unmarshal(data, &record) != nil || record.LatestVersion == "" {
+		return checkRecord{}, false
 	}
-
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		info.Error = err
-		return info
+	if record.APIURL != c.client.APIURL || record.Repository != c.repositoryURL {
+		return checkRecord{}, false
 	}
+	// A clock set back would otherwise keep the record forever
+	age := time.Since(record.CheckedAt)
+	return record, age >= 0 && age < c.interval
+}
 
-	// Remove 'v' prefix if present
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	info.LatestVersion = latestVersion
-
-	// Compare versions
-	if compareVersions(c.currentVersion, latestVersion) < 0 {
-		info.HasUpdate = true
+// saveCheck records the result of a check. Failing to write it only means
+// the next launch checks again.
+func (c *Checker) saveCheck(record checkRecord) {
+	if c.cachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(c.cachePath), 0o755) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.cachePath), filepath.Base(c.cachePath)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), c.cachePath) != nil {
+		os.Remove(tmp.Name())
 	}
-
-	return info
 }
 
 // OpenRepository opens the repository URL in the default browser
@@ -109,40 +166,3 @@ func (c *Checker) OpenRepository() error {
 
 	return cmd.Start()
 }
-
-// compareVersions compares two semantic version strings
-// Returns: -1 if v1 < v2, 0 if v1 == v2, 1 if v1 > v2
-func compareVersions(v1, v2 string) int {
-	// Remove 'v' prefix if present
-	v1 = strings.TrimPrefix(v1, "v")
-	v2 = strings.TrimPrefix(v2, "v")
-
-	// Split versions into parts
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	// Compare each part
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		var p1, p2 int
-
-		if i < len(parts1) {
-			fmt.Sscanf(parts1[i], "%d", &p1)
-		}
-		if i < len(parts2) {
-			fmt.Sscanf(parts2[i], "%d", &p2)
-		}
-
-		if p1 < p2 {
-			return -1
-		} else if p1 > p2 {
-			return 1
-		}
-	}
-
-	return 0
-}
@@ -0,0 +1,85 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// newReleaseServer serves tag as the latest release of every repository and
+// counts the requests
+func newReleaseServer(t *testing.T, tag string, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprintf(w, `{"tag_name": %q}`, tag)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestChecker creates a checker for version 1.0.0 that reads releases
+// from server and keeps its result in path
+func newTestChecker(server *httptest.Server, repositoryURL, path string) *Checker {
+	c := NewChecker("1.0.0", repositoryURL)
+	c.SetClient(&github.Client{APIURL: server.URL + "/", HTTPClient: server.Client()})
+	c.SetCache(path, time.Hour)
+	return c
+}
+
+func TestCheckForUpdatesCache(t *testing.T) {
+	const repository = "https://github.com/xiaoxu123195/atm"
+	var requests, otherRequests atomic.Int32
+	server := newReleaseServer(t, "v1.1.0", &requests)
+	other := newReleaseServer(t, "v2.0.0", &otherRequests)
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	ctx := context.Background()
+
+	info := newTestChecker(server, repository, path).CheckForUpdates(ctx)
+	if info.Error != nil || !info.HasUpdate || info.LatestVersion != "1.1.0" || info.Cached {
+		t.Fatalf("first check = %+v", info)
+	}
+
+	info = newTestChecker(server, repository, path).CheckForUpdates(ctx)
+	if !info.Cached || info.LatestVersion != "1.1.0" || requests.Load() != 1 {
+		t.Errorf("second check = %+v after %d requests, want the cached result", info, requests.Load())
+	}
+
+	// A record from another API is not reused
+	info = newTestChecker(other, repository, path).CheckForUpdates(ctx)
+	if info.Cached || info.LatestVersion != "2.0.0" || otherRequests.Load() != 1 {
+		t.Errorf("check against another API = %+v, want a new request", info)
+	}
+
+	// Nor is one from another repository
+	info = newTestChecker(other, "https://github.com/acme/atm-fork", path).CheckForUpdates(ctx)
+	if info.Cached || otherRequests.Load() != 2 {
+		t.Errorf("check of another repository = %+v, want a new request", info)
+	}
+
+	c := newTestChecker(server, repository, path)
+	c.SetCache(path, 0)
+	if info := c.CheckForUpdates(ctx); info.Cached || requests.Load() != 2 {
+		t.Errorf("check without an interval = %+v, want a new request", info)
+	}
+}
+
+func TestCheckForUpdatesCanceled(t *testing.T) {
+	var requests atomic.Int32
+	server := newReleaseServer(t, "v1.1.0", &requests)
+	c := newTestChecker(server, "https://github.com/xiaoxu123195/atm", filepath.Join(t.TempDir(), "update-check.json"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if info := c.CheckForUpdates(ctx); !errors.Is(info.Error, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", info.Error)
+	}
+}
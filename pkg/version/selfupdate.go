@@ -0,0 +1,185 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// ErrNoChecksum means a release publishes no checksum for the atm binary, so
+// a self-update cannot verify the download
+var ErrNoChecksum = errors.New("the release publishes no checksum for the atm binary")
+
+// ErrNoBackup means there is no executable kept by a previous self-update to
+// roll back to
+var ErrNoBackup = errors.New("no previous atm executable was kept")
+
+// AssetName returns the name of the release asset holding the atm binary for
+// a platform, as built by make cross-compile, e.g. atm-linux-amd64 or
+// atm-windows-amd64.exe
+func AssetName(goos, goarch string) string {
+	name := "atm-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Release reads the release of a version, or the latest release if version
+// is empty
+func (c *Checker) Release(ctx context.Context, version string) (*github.Release, error) {
+	repo, ok := github.ParseRepository(c.repositoryURL)
+	if !ok {
+		return nil, fmt.Errorf("invalid repository URL")
+	}
+	if version == "" {
+		return c.client.LatestRelease(ctx, repo)
+	}
+	return c.client.ReleaseByVersion(ctx, repo, version)
+}
+
+// Download saves the atm binary of a release for this platform to a
+// temporary file in dir and verifies it against the checksum the release
+// publishes. It returns the path of the file, which the caller removes if it
+// does not Replace the executable with it.
+func (c *Checker) Download(ctx context.Context, release *github.Release, dir string) (string, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	asset, ok := release.Asset(name)
+	if !ok {
+		return "", fmt.Errorf("release %s has no asset %s", release.TagName, name)
+	}
+	sum, ok, err := c.client.Checksum(ctx, release, name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrNoChecksum
+	}
+
+	file, err := os.CreateTemp(dir, ".atm-update-*")
+	if err != nil {
+		return "", err
+	}
+	err = c.client.Download(ctx, asset, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = github.VerifyFile(file.Name(), name, sum)
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0o755)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// Executable returns the path of the running atm executable, resolving symlinks
+func Executable() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(executable)
+}
+
+// BackupPath returns where Replace keeps the executable it replaces
+func BackupPath(executable string) string {
+	return executable + ".bak"
+}
+
+// Replace replaces the executable with the binary at newPath, keeping the
+// replaced one at BackupPath(executable). newPath must be in the same
+// directory, so the replacement is a rename: the executable is never missing
+// or half-written.
+func Replace(executable, newPath string) error {
+	info, err := os.Stat(executable)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(newPath, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// Left behind by a rollback while the old executable was running
+		os.Remove(executable + ".old")
+		return moveAside(executable, newPath, BackupPath(executable))
+	}
+	if err := copyFile(executable, BackupPath(executable)); err != nil {
+		return err
+	}
+	return os.Rename(newPath, executable)
+}
+
+// Rollback restores the executable kept by the last Replace
+func Rollback(executable string) error {
+	backup := BackupPath(executable)
+	if _, err := os.Stat(backup); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNoBackup
+		}
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// The running executable cannot be deleted; the next Replace removes it
+		return moveAside(executable, backup, executable+".old")
+	}
+	return os.Rename(backup, executable)
+}
+
+// moveAside renames the executable to keep, then moves newPath into its
+// place. Windows cannot replace a running executable, but can rename it.
+func moveAside(executable, newPath, keep string) error {
+	os.Remove(keep)
+	if err := os.Rename(executable, keep); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, executable); err != nil {
+		os.Rename(keep, executable)
+		return err
+	}
+	return nil
+}
+
+// copyFile copies a file with its permissions, replacing dst atomically
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(out.Name(), info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(out.Name())
+	}
+	return err
+}
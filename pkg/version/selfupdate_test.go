@@ -0,0 +1,200 @@
+package version
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xiaoxu123195/atm/pkg/github"
+)
+
+// writeExecutable writes an executable file with the given content and mode
+func writeExecutable(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkFile fails the test unless path holds content with mode
+func checkFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("%s holds %q, want %q", filepath.Base(path), data, content)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("%s has mode %v, want %v", filepath.Base(path), info.Mode().Perm(), mode)
+	}
+}
+
+func TestReplaceAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "atm")
+	update := filepath.Join(dir, ".atm-update-1")
+	writeExecutable(t, executable, "old atm", 0o750)
+	writeExecutable(t, update, "new atm", 0o600)
+
+	if err := Replace(executable, update); err != nil {
+		t.Fatal(err)
+	}
+	// The new binary takes the mode of the executable it replaces
+	checkFile(t, executable, "new atm", 0o750)
+	checkFile(t, BackupPath(executable), "old atm", 0o750)
+	if _, err := os.Stat(update); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the downloaded file is still there: %v", err)
+	}
+
+	if err := Rollback(executable); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, executable, "old atm", 0o750)
+	if err := Rollback(executable); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("second Rollback = %v, want ErrNoBackup", err)
+	}
+}
+
+func TestRollbackWithoutBackup(t *testing.T) {
+	executable := filepath.Join(t.TempDir(), "atm")
+	writeExecutable(t, executable, "atm", 0o755)
+
+	if err := Rollback(executable); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("Rollback = %v, want ErrNoBackup", err)
+	}
+	checkFile(t, executable, "atm", 0o755)
+}
+
+func TestReplaceMissingExecutable(t *testing.T) {
+	dir := t.TempDir()
+	update := filepath.Join(dir, ".atm-update-1")
+	writeExecutable(t, update, "new atm", 0o755)
+
+	if err := Replace(filepath.Join(dir, "atm"), update); err == nil {
+		t.Error("Replace succeeded without an executable to replace")
+	}
+	if _, err := os.Stat(BackupPath(filepath.Join(dir, "atm"))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("a backup was created: %v", err)
+	}
+}
+
+func TestMoveAside(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "atm")
+	keep := filepath.Join(dir, "atm.old")
+	update := filepath.Join(dir, ".atm-update-1")
+	writeExecutable(t, executable, "old atm", 0o755)
+	writeExecutable(t, keep, "stale", 0o755)
+	writeExecutable(t, update, "new atm", 0o755)
+
+	if err := moveAside(executable, update, keep); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, executable, "new atm", 0o755)
+	checkFile(t, keep, "old atm", 0o755)
+}
+
+func TestMoveAsideRestoresOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "atm")
+	keep := filepath.Join(dir, "atm.bak")
+	writeExecutable(t, executable, "old atm", 0o755)
+
+	// The new binary is missing, so moving it into place fails
+	if err := moveAside(executable, filepath.Join(dir, "missing"), keep); err == nil {
+		t.Fatal("moveAside succeeded without a new binary")
+	}
+	checkFile(t, executable, "old atm", 0o755)
+	if _, err := os.Stat(keep); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%s was left behind: %v", filepath.Base(keep), err)
+	}
+}
+
+// sha256Hex returns the SHA256 of data in hex
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	const binary = "atm binary"
+	tests := []struct {
+		name    string
+		assets  map[string]string
+		wantErr error
+	}{
+		{"checksums file", map[string]string{name: binary, "checksums.txt": sha256Hex(binary) + "  " + name + "\n"}, nil},
+		{"sha256 file", map[string]string{name: binary, name + ".sha256": sha256Hex(binary) + "\n"}, nil},
+		{"no checksum", map[string]string{name: binary}, ErrNoChecksum},
+		{"checksums file without the binary", map[string]string{name: binary, "checksums.txt": sha256Hex(binary) + "  atm-plan9-386\n"}, github.ErrNoChecksum},
+		{"checksum mismatch", map[string]string{name: "tampered", "checksums.txt": sha256Hex(binary) + "  " + name + "\n"}, github.ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, ok := tt.assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(data))
+			}))
+			defer server.Close()
+
+			release := &github.Release{TagName: "v2.0.0"}
+			for asset := range tt.assets {
+				release.Assets = append(release.Assets, github.Asset{Name: asset, URL: server.URL + "/download/" + asset})
+			}
+			c := NewChecker("1.0.0", "https://github.com/xiaoxu123195/atm")
+			c.SetClient(&github.Client{APIURL: server.URL + "/", HTTPClient: server.Client()})
+			dir := t.TempDir()
+
+			path, err := c.Download(context.Background(), release, dir)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Download error = %v, want %v", err, tt.wantErr)
+				}
+				if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+					t.Errorf("Download left %d files behind", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.Dir(path) != dir {
+				t.Errorf("downloaded to %s, want a file in %s", path, dir)
+			}
+			checkFile(t, path, binary, 0o755)
+		})
+	}
+}
+
+func TestDownloadMissingAsset(t *testing.T) {
+	release := &github.Release{TagName: "v2.0.0", Assets: []github.Asset{{Name: "atm-plan9-386"}}}
+	c := NewChecker("1.0.0", "https://github.com/xiaoxu123195/atm")
+	if _, err := c.Download(context.Background(), release, t.TempDir()); err == nil || !strings.Contains(err.Error(), "has no asset") {
+		t.Errorf("Download error = %v, want a missing asset", err)
+	}
+}